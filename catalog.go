@@ -0,0 +1,179 @@
+package rpc
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync/atomic"
+)
+
+// ErrorDesc describes a business error code.
+type ErrorDesc struct {
+	Code        string       `json:"code"`
+	Description string       `json:"description,omitempty"`
+	DataType    reflect.Type `json:"-"`
+	Shared      bool         `json:"shared,omitempty"`
+	Methods     []string     `json:"methods,omitempty"`
+}
+
+// ErrorCodeValidation is returned if the request fields are invalid, the data is []ValidationError.
+const ErrorCodeValidation = "VALIDATION_ERROR"
+
+var builtinErrors = []ErrorDesc{
+	{Code: "INVALID_JSON", Description: "Cannot parse JSON", Shared: true},
+	{Code: ErrorCodeOverloaded, Description: "Too many concurrent calls", Shared: true},
+	{Code: ErrorCodeValidation, Description: "Invalid request fields", DataType: reflect.TypeOf([]ValidationError{}), Shared: true},
+}
+
+type errorCatalog struct {
+	errors     map[string]string
+	errorTypes map[string]reflect.Type
+}
+
+// RegisterErrors binds the pointer to the structure with the ErrorFunc or ErrorFuncT fields
+// as the errors shared by all the methods, e.g. UNAUTHORIZED or NOT_FOUND.
+// The shared errors are included in the documentation of each method.
+func (r *Rpc) RegisterErrors(errors interface{}) error {
+	r.registry.mu.Lock()
+	defer r.registry.mu.Unlock()
+
+	catalog := errorCatalog{
+		errors:     map[string]string{},
+		errorTypes: map[string]reflect.Type{},
+	}
+	cur := r.registry.loadCatalog()
+	for code, desc := range cur.errors {
+		catalog.errors[code] = desc
+	}
+	for code, t := range cur.errorTypes {
+		catalog.errorTypes[code] = t
+	}
+
+	var bindings []errorBinding
+	if problems := bindErrorsStruct("shared errors", reflect.ValueOf(errors), catalog.errors, catalog.errorTypes, &bindings); len(problems) > 0 {
+		return &RegistrationError{Method: reflect.TypeOf(errors).String(), Problems: problems}
+	}
+	setErrorFuncs(bindings)
+
+	r.registry.catalog.Store(catalog)
+	r.registry.commit(r.registry.load(), nil)
+
+	// The errors are documented by the parents for the mounted methods
+	for p := r.parent; p != nil; p = p.parent {
+		atomic.AddUint64(&p.registry.version, 1)
+	}
+
+	return nil
+}
+
+func (reg *registry) loadCatalog() errorCatalog {
+	catalog, _ := reg.catalog.Load().(errorCatalog)
+	return catalog
+}
+
+// sharedErrors returns the shared errors of the Rpc and its parents.
+func (r *Rpc) sharedErrors() errorCatalog {
+	res := errorCatalog{
+		errors:     map[string]string{},
+		errorTypes: map[string]reflect.Type{},
+	}
+
+	// Without the locks, so the spec can be rebuilt by the subscribers
+	for p := r; p != nil; p = p.parent {
+		catalog := p.registry.loadCatalog()
+		for code, desc := range catalog.errors {
+			if _, exists := res.errors[code]; !exists {
+				res.errors[code] = desc
+				res.errorTypes[code] = catalog.errorTypes[code]
+			}
+		}
+	}
+
+	return res
+}
+
+// MethodErrors returns the business errors of the method including the shared ones, sorted by the code.
+func (r *Rpc) MethodErrors(path string) []ErrorDesc {
+	owner, md := r.lookup(path)
+	if md == nil {
+		return nil
+	}
+
+	shared := owner.sharedErrors()
+
+	var res []ErrorDesc
+	for code, desc := range shared.errors {
+		if _, exists := md.Errors[code]; !exists {
+			res = append(res, ErrorDesc{Code: code, Description: desc, DataType: shared.errorTypes[code], Shared: true})
+		}
+	}
+
+	for code, desc := range md.Errors {
+		res = append(res, ErrorDesc{Code: code, Description: desc, DataType: md.ErrorTypes[code]})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Code < res[j].Code
+	})
+
+	return res
+}
+
+// GetErrors returns the error codes of the public methods: the built-in, the shared and the methods ones.
+// The Methods field lists the paths of the methods which declare the error.
+func (r *Rpc) GetErrors() []ErrorDesc {
+	return r.getErrors(r.GetPublicPaths())
+}
+
+// GetInternalErrors returns the error codes of all the methods including the Hidden and Internal ones.
+func (r *Rpc) GetInternalErrors() []ErrorDesc {
+	return r.getErrors(r.GetPaths())
+}
+
+func (r *Rpc) getErrors(paths []string) []ErrorDesc {
+	byCode := map[string]*ErrorDesc{}
+	for _, e := range builtinErrors {
+		e := e
+		byCode[e.Code] = &e
+	}
+
+	for _, path := range paths {
+		for _, e := range r.MethodErrors(path) {
+			existing := byCode[e.Code]
+			if existing == nil {
+				e := e
+				existing = &e
+				byCode[e.Code] = existing
+			}
+
+			if !e.Shared {
+				existing.Methods = append(existing.Methods, path)
+			}
+		}
+	}
+
+	res := make([]ErrorDesc, 0, len(byCode))
+	for _, e := range byCode {
+		res = append(res, *e)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Code < res[j].Code
+	})
+
+	return res
+}
+
+func (r *Rpc) serveErrors(w http.ResponseWriter, internal bool) {
+	errors := r.GetErrors()
+	if internal {
+		errors = r.GetInternalErrors()
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(errors); err != nil {
+		log.Printf("Cannot marshal errors: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mPing "github.com/go-qbit/rpc/internal/test/method/ping"
+)
+
+type commonErrors struct {
+	NOT_FOUND rpc.ErrorFunc `desc:"The object is not found"`
+}
+
+var sharedErrors struct {
+	UNAUTHORIZED rpc.ErrorFunc `desc:"The API key is invalid"`
+}
+
+var updateUserErrors struct {
+	commonErrors
+	NAME_TAKEN rpc.ErrorFunc `desc:"The name is taken"`
+}
+
+func TestRpc_RegisterErrors(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+
+	if err := r.RegisterErrors(&sharedErrors); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.RegisterMethod(mPing.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rpc.Handle(r, "/users/update/v1", func(ctx context.Context, req *createUserReq) (*createUserResp, error) {
+		return nil, updateUserErrors.NOT_FOUND("no user")
+	}, rpc.Errors(&updateUserErrors)); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := srv.Client().Post(srv.URL+"/users/update/v1", "application/json", strings.NewReader(`{"name": "user"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rpcErr rpc.Error
+	_ = json.NewDecoder(resp.Body).Decode(&rpcErr)
+	resp.Body.Close()
+	if rpcErr.Code != "NOT_FOUND" {
+		t.Fatalf("Invalid error code field = '%s', expected 'NOT_FOUND'", rpcErr.Code)
+	}
+
+	swagger := r.GetSwagger(context.Background())
+	for _, path := range []string{"/ping/v1", "/users/update/v1"} {
+		if !strings.Contains(swagger.Paths[path].Post.Responses["400"].Description, "UNAUTHORIZED") {
+			t.Fatalf("No shared error in the %s description", path)
+		}
+	}
+
+	resp, err = srv.Client().Get(srv.URL + "/_errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errors []rpc.ErrorDesc
+	if err := json.NewDecoder(resp.Body).Decode(&errors); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	codes := map[string]rpc.ErrorDesc{}
+	for _, e := range errors {
+		codes[e.Code] = e
+	}
+
+	for _, code := range []string{"INVALID_JSON", "OVERLOADED", "UNAUTHORIZED", "NOT_FOUND", "NAME_TAKEN"} {
+		if _, exists := codes[code]; !exists {
+			t.Fatalf("No code %s in the errors catalog", code)
+		}
+	}
+
+	if methods := codes["NOT_FOUND"].Methods; len(methods) != 1 || methods[0] != "/users/update/v1" {
+		t.Fatalf("Invalid NOT_FOUND methods %v", methods)
+	}
+}
+
+// The shared errors are returned by the calls while they are registered again, run with -race.
+func TestRpc_RegisterErrors_Race(t *testing.T) {
+	r := rpc.New("")
+	if err := r.RegisterErrors(&sharedErrors); err != nil {
+		t.Fatal(err)
+	}
+	if err := rpc.Handle(r, "/auth/v1", func(ctx context.Context, req *struct{}) (*struct{}, error) {
+		return nil, sharedErrors.UNAUTHORIZED("no key")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := r.Invoke(context.Background(), "/auth/v1", []byte(`{}`))
+			var rpcErr *rpc.Error
+			if !errors.As(err, &rpcErr) || rpcErr.Code != "UNAUTHORIZED" {
+				t.Errorf("Invalid error %v, expected UNAUTHORIZED", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := rpc.New("").RegisterErrors(&sharedErrors); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var invalidErrors struct {
+		FORBIDDEN rpc.ErrorFunc
+		Invalid   func()
+	}
+	if err := r.RegisterErrors(&invalidErrors); err == nil {
+		t.Fatal("The invalid errors are registered")
+	}
+	if invalidErrors.FORBIDDEN != nil {
+		t.Fatal("The errors of the rejected registration are bound")
+	}
+}
+
+func TestRpc_GetErrors_Visibility(t *testing.T) {
+	var secretErrors struct {
+		SecretCode rpc.ErrorFunc `desc:"The secret error"`
+	}
+
+	r := rpc.New("")
+	if err := rpc.Handle(r, "/secret/v1", func(ctx context.Context, req *struct{}) (*struct{}, error) {
+		return &struct{}{}, nil
+	}, rpc.Errors(&secretErrors), rpc.SetVisibility(rpc.Internal)); err != nil {
+		t.Fatal(err)
+	}
+
+	hasCode := func(errors []rpc.ErrorDesc) bool {
+		for _, e := range errors {
+			if e.Code == "SecretCode" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasCode(r.GetErrors()) {
+		t.Fatalf("The internal method error is public")
+	}
+	if !hasCode(r.GetInternalErrors()) {
+		t.Fatalf("No internal method error")
+	}
+
+	for handler, expected := range map[http.Handler]bool{r: false, r.Internal(): true} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_errors", nil))
+
+		var errors []rpc.ErrorDesc
+		if err := json.Unmarshal(w.Body.Bytes(), &errors); err != nil {
+			t.Fatal(err)
+		}
+		if hasCode(errors) != expected {
+			t.Fatalf("Invalid /_errors %s, expected the secret code: %v", w.Body, expected)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RegistrationError lists all the problems found in a method during the registration.
+type RegistrationError struct {
+	Method   string
+	Problems []error
+}
+
+func (e *RegistrationError) Error() string {
+	b := &strings.Builder{}
+	b.WriteString("cannot register method ")
+	b.WriteString(e.Method)
+	b.WriteString(":")
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.Error())
+	}
+
+	return b.String()
+}
+
+func checkType(t reflect.Type, curPath string, visited map[reflect.Type]bool) []error {
+	if visited[t] {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return checkType(t.Elem(), curPath, visited)
+
+	case reflect.Slice, reflect.Array:
+		return checkType(t.Elem(), curPath+"[]", visited)
+
+	case reflect.Struct:
+		visited[t] = true
+
+		var problems []error
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" || strings.Split(f.Tag.Get("json"), ",")[0] == "-" {
+				continue
+			}
+
+			problems = append(problems, checkType(f.Type, curPath+"/"+f.Name, visited)...)
+		}
+
+		return problems
+
+	case reflect.Map:
+		var problems []error
+		switch t.Key().Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			problems = append(problems, fmt.Errorf("%s: unsupported map key type %s", curPath, t.Key()))
+		}
+
+		return append(problems, checkType(t.Elem(), curPath+"[]", visited)...)
+
+	case reflect.String, reflect.Bool, reflect.Interface, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return nil
+
+	default:
+		return []error{fmt.Errorf("%s: unsupported type %s", curPath, t)}
+	}
+}
@@ -0,0 +1,57 @@
+package rpc_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mBroken "github.com/go-qbit/rpc/internal/test/method/broken"
+	mHello "github.com/go-qbit/rpc/internal/test/method/hello"
+)
+
+func TestRpc_RegisterMethod_Problems(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+
+	err := r.RegisterMethod(mBroken.New())
+
+	var regErr *rpc.RegistrationError
+	if !errors.As(err, &regErr) {
+		t.Fatalf("Expected *rpc.RegistrationError, got %v", err)
+	}
+
+	for _, problem := range []string{
+		"V1 request/Ch: unsupported type chan int",
+		"V1 request/Cmplx: unsupported type complex128",
+		"V1 request/Int: invalid validator",
+		"invalid method V2 signature",
+		"ErrorsV3 has no corresponding method V3",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("No problem '%s' in the error:\n%v", problem, err)
+		}
+	}
+
+	if len(r.GetPaths()) != 0 {
+		t.Fatalf("Broken method is registered: %v", r.GetPaths())
+	}
+}
+
+func TestRpc_RegisterMethod_InvalidValidator(t *testing.T) {
+	_, err := rpc.ParseMethodDesc(mBroken.New(), "github.com/go-qbit/rpc/internal/test/method")
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
+
+func TestRpc_RegisterMethod_DuplicatePath(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mHello.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := r.RegisterMethod(mHello.New())
+	if err == nil || !strings.Contains(err.Error(), "path /hello/v1 is already registered") {
+		t.Fatalf("Expected the duplicate path error, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package typescript
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"hash/crc32"
 	"io"
@@ -10,94 +12,218 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/go-qbit/rpc"
 )
 
+// New returns the handler serving the TypeScript client for the rpc methods.
+// The generated code is cached until the methods set is changed, see rpc.Version,
+// so the captions and descriptions are got with the context of the request which generated the code.
+// They must not depend on the context, e.g. on the language of the request.
 func New(rpc *rpc.Rpc, prefix string) http.HandlerFunc {
+	var (
+		mu      sync.Mutex
+		version uint64
+		code    []byte
+	)
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		types := map[string]reflect.Type{}
+		mu.Lock()
+		if code == nil || version != rpc.Version() {
+			version = rpc.Version()
+			buf := &bytes.Buffer{}
+			generate(r.Context(), rpc, prefix, buf)
+			code = buf.Bytes()
+		}
+		res := code
+		mu.Unlock()
+
+		_, _ = w.Write(res)
+	}
+}
 
-		methodsCode := &bytes.Buffer{}
+func generate(ctx context.Context, rpc *rpc.Rpc, prefix string, w io.Writer) {
+	// The request fields are optional unless required, the response fields if they may be absent
+	requestTypes, responseTypes := map[string]reflect.Type{}, map[string]reflect.Type{}
 
-		for _, path := range rpc.GetPaths() {
-			m := rpc.GetMethod(path)
+	methodsCode := &bytes.Buffer{}
+	errorsCode := &bytes.Buffer{}
 
-			methodNameParts := strings.Split(strings.TrimPrefix(path, "/"), "/")
-			for i, s := range methodNameParts {
-				methodNameParts[i] = strings.ToUpper(s[:1]) + s[1:]
-			}
-			methodName := strings.Join(methodNameParts, "")
-
-			methodsCode.WriteString("\n\n  // ")
-			methodsCode.WriteString(m.Method.Description(r.Context()))
-			methodsCode.WriteString("\n")
-			methodsCode.WriteString(`  public static `)
-			methodsCode.WriteString(methodName)
-			methodsCode.WriteString("(request: ")
-			methodsCode.WriteString(toTsTypeName(m.Request, prefix))
-			methodsCode.WriteString("): Promise<")
-			methodsCode.WriteString(toTsTypeName(m.Response, prefix))
-			methodsCode.WriteString("> {\n    return this.post('")
-			methodsCode.WriteString(path)
-			methodsCode.WriteString("', request, '")
-			methodsCode.WriteString(checkContentType(m.Request))
-			methodsCode.WriteString("') as Promise<")
-			methodsCode.WriteString(toTsTypeName(m.Response, prefix))
-			methodsCode.WriteString(">\n  }")
-
-			addTsStructTypes(m.Request, prefix, types)
-			addTsStructTypes(m.Response, prefix, types)
+	for _, path := range rpc.GetPublicPaths() {
+		m := rpc.GetMethod(path)
+		if m == nil { // Unregistered concurrently
+			continue
 		}
 
-		methodsCode.WriteString("\n}")
+		methodNameParts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		for i, s := range methodNameParts {
+			methodNameParts[i] = strings.ToUpper(s[:1]) + s[1:]
+		}
+		methodName := strings.Join(methodNameParts, "")
+
+		writeErrorsType(errorsCode, methodName, m.Request != nil, rpc.MethodErrors(path), prefix, responseTypes)
+
+		methodsCode.WriteString("\n\n  // ")
+		methodsCode.WriteString(m.Method.Description(ctx))
+		methodsCode.WriteString("\n  // Rejects with ")
+		methodsCode.WriteString(methodName)
+		methodsCode.WriteString("Error")
+		methodsCode.WriteString("\n")
+		requestArg, requestValue, contentType := "", "undefined", "application/json"
+		if m.Request != nil {
+			requestArg = "request: " + toTsTypeName(m.Request, prefix)
+			requestValue = "request"
+			contentType = checkContentType(m.Request)
+			addTsStructTypes(m.Request, prefix, requestTypes)
+		}
 
-		typesNames := make([]string, 0, len(types))
-		for t := range types {
-			typesNames = append(typesNames, t)
+		responseType := "void"
+		if m.Response != nil {
+			responseType = toTsTypeName(m.Response, prefix)
+			addTsStructTypes(m.Response, prefix, responseTypes)
 		}
-		sort.Strings(typesNames)
-
-		for _, name := range typesNames {
-			_, _ = io.WriteString(w, "export type ")
-			_, _ = io.WriteString(w, name)
-
-			if types[name].NumField() > 0 {
-				_, _ = io.WriteString(w, " = {")
-
-				for i := 0; i < types[name].NumField(); i++ {
-					field := types[name].Field(i)
-					name := field.Tag.Get("json")
-					name = strings.Split(name, ",")[0]
-					if name == "" {
-						name = field.Name
-					}
-					if name == "-" {
-						continue
-					}
-
-					_, _ = io.WriteString(w, "\n  ")
-					_, _ = io.WriteString(w, name)
-					if field.Type.Kind() == reflect.Ptr {
-						_, _ = io.WriteString(w, "?")
-					}
-					_, _ = io.WriteString(w, ": ")
-					_, _ = io.WriteString(w, toTsTypeName(field.Type, prefix))
-
-					if description := field.Tag.Get("desc"); description != "" {
-						_, _ = io.WriteString(w, "  // ")
-						_, _ = io.WriteString(w, description)
-					}
+
+		methodsCode.WriteString(`  public static `)
+		methodsCode.WriteString(methodName)
+		methodsCode.WriteString("(")
+		methodsCode.WriteString(requestArg)
+		methodsCode.WriteString("): Promise<")
+		methodsCode.WriteString(responseType)
+		methodsCode.WriteString("> {\n    return this.post('")
+		methodsCode.WriteString(rpc.MountPrefix())
+		methodsCode.WriteString(path)
+		methodsCode.WriteString("', ")
+		methodsCode.WriteString(requestValue)
+		methodsCode.WriteString(", '")
+		methodsCode.WriteString(contentType)
+		methodsCode.WriteString("') as Promise<")
+		methodsCode.WriteString(responseType)
+		methodsCode.WriteString(">\n  }")
+	}
+
+	methodsCode.WriteString("\n}")
+
+	types := map[string]reflect.Type{}
+	for name, t := range requestTypes {
+		types[name] = t
+	}
+	for name, t := range responseTypes {
+		types[name] = t
+	}
+
+	typesNames := make([]string, 0, len(types))
+	for t := range types {
+		typesNames = append(typesNames, t)
+	}
+	sort.Strings(typesNames)
+
+	for _, name := range typesNames {
+		_, _ = io.WriteString(w, "export type ")
+		_, _ = io.WriteString(w, name)
+
+		if types[name].Kind() != reflect.Struct {
+			_, _ = io.WriteString(w, " = ")
+			_, _ = io.WriteString(w, toTsEnumUnion(types[name]))
+			_, _ = io.WriteString(w, "\n\n")
+		} else if types[name].NumField() > 0 {
+			_, _ = io.WriteString(w, " = {")
+
+			_, isRequest := requestTypes[name]
+			_, isResponse := responseTypes[name]
+
+			for i := 0; i < types[name].NumField(); i++ {
+				field := types[name].Field(i)
+				name := field.Tag.Get("json")
+				name = strings.Split(name, ",")[0]
+				if name == "" {
+					name = field.Name
+				}
+				if name == "-" {
+					continue
 				}
-				_, _ = io.WriteString(w, "\n}\n\n")
-			} else {
-				_, _ = io.WriteString(w, " = Record<string, never>\n\n")
+
+				_, _ = io.WriteString(w, "\n  ")
+				_, _ = io.WriteString(w, name)
+				if isRequest && !isRequired(field) || isResponse && isResponseOptional(field) {
+					_, _ = io.WriteString(w, "?")
+				}
+				_, _ = io.WriteString(w, ": ")
+				_, _ = io.WriteString(w, toTsFieldTypeName(field, prefix))
+
+				if comment := fieldComment(field); comment != "" {
+					_, _ = io.WriteString(w, "  // ")
+					_, _ = io.WriteString(w, comment)
+				}
+			}
+			_, _ = io.WriteString(w, "\n}\n\n")
+		} else {
+			_, _ = io.WriteString(w, " = Record<string, never>\n\n")
+		}
+	}
+
+	writeErrorCodes(w, rpc.GetErrors())
+	_, _ = errorsCode.WriteTo(w)
+	_, _ = io.WriteString(w, tsLibBody)
+	_, _ = methodsCode.WriteTo(w)
+}
+
+// fieldComment returns the field description with the default value.
+func fieldComment(field reflect.StructField) string {
+	comment := field.Tag.Get("desc")
+
+	if value, exists, err := rpc.GetDefault(field); err == nil && exists {
+		if data, err := json.Marshal(value); err == nil {
+			if comment != "" {
+				comment = strings.TrimSuffix(comment, ".") + ". "
 			}
+			comment += "Default: " + string(data)
 		}
+	}
+
+	return comment
+}
 
-		_, _ = io.WriteString(w, tsLibBody)
-		_, _ = methodsCode.WriteTo(w)
+// writeErrorsType writes the union of the method business errors discriminated by the code.
+// The methods with the request can fail the fields validation.
+func writeErrorsType(w io.Writer, methodName string, hasRequest bool, errors []rpc.ErrorDesc, prefix string, types map[string]reflect.Type) {
+	_, _ = io.WriteString(w, "export type ")
+	_, _ = io.WriteString(w, methodName)
+	_, _ = io.WriteString(w, "Error =\n  | ApiError<'INVALID_JSON'>")
+	if hasRequest {
+		_, _ = io.WriteString(w, "\n  | ApiError<'VALIDATION_ERROR', ValidationError[]>")
+	}
+
+	for _, e := range errors {
+		_, _ = io.WriteString(w, "\n  | ApiError<'")
+		_, _ = io.WriteString(w, e.Code)
+		_, _ = io.WriteString(w, "'")
+		if e.DataType != nil {
+			_, _ = io.WriteString(w, ", ")
+			_, _ = io.WriteString(w, toTsTypeName(e.DataType, prefix))
+			addTsStructTypes(e.DataType, prefix, types)
+		}
+		_, _ = io.WriteString(w, ">")
 	}
+
+	_, _ = io.WriteString(w, "\n\n")
+}
+
+// writeErrorCodes writes the constant map of all the error codes.
+func writeErrorCodes(w io.Writer, errors []rpc.ErrorDesc) {
+	_, _ = io.WriteString(w, "export const ErrorCodes = {")
+	for _, e := range errors {
+		_, _ = io.WriteString(w, "\n  ")
+		_, _ = io.WriteString(w, e.Code)
+		_, _ = io.WriteString(w, ": '")
+		_, _ = io.WriteString(w, e.Code)
+		_, _ = io.WriteString(w, "',")
+		if e.Description != "" {
+			_, _ = io.WriteString(w, "  // ")
+			_, _ = io.WriteString(w, e.Description)
+		}
+	}
+	_, _ = io.WriteString(w, "\n} as const\n\nexport type ErrorCode = typeof ErrorCodes[keyof typeof ErrorCodes]\n\n")
 }
 
 func toTsTypeName(varType reflect.Type, prefix string) string {
@@ -111,8 +237,12 @@ func toTsTypeName(varType reflect.Type, prefix string) string {
 	}
 	typePrefix := strings.Join(typeParts, "")
 
+	if isTsEnum(varType) {
+		return typePrefix + strings.Title(varType.Name())
+	}
+
 	switch varType.Kind() {
-	case reflect.Slice:
+	case reflect.Slice, reflect.Array:
 		return toTsTypeName(varType.Elem(), prefix) + "[]"
 	case reflect.Struct:
 		sName := varType.Name()
@@ -143,11 +273,103 @@ func toTsTypeName(varType reflect.Type, prefix string) string {
 	}
 }
 
+// isRequired is used in generate where the rpc package name is shadowed.
+func isRequired(field reflect.StructField) bool {
+	return rpc.IsRequired(field)
+}
+
+// isResponseOptional is used in generate where the rpc package name is shadowed.
+func isResponseOptional(field reflect.StructField) bool {
+	return rpc.IsResponseOptional(field)
+}
+
+// isTsEnum reports whether the type is the named EnumProvider type declared as the union of its values.
+func isTsEnum(t reflect.Type) bool {
+	if t.Name() == "" || t.Kind() == reflect.Struct {
+		return false
+	}
+
+	values, err := rpc.GetTypeEnum(t)
+
+	return err == nil && values != nil
+}
+
+// toTsFieldTypeName returns the union of the values for the fields with the enum tag, the format type
+// for the fields with the format tag and the type name for the rest.
+func toTsFieldTypeName(field reflect.StructField, prefix string) string {
+	_, isEnum := field.Tag.Lookup("enum")
+	format, _ := rpc.GetFormat(field.Tag.Get("format"))
+	if !isEnum && format.TsType == "" {
+		return toTsTypeName(field.Type, prefix)
+	}
+
+	t := field.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// The tags of the slice or map field are applied to the items
+	elemField := field
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		elemField.Type = t.Elem()
+		elemType := toTsFieldTypeName(elemField, prefix)
+		if strings.Contains(elemType, " ") {
+			elemType = "(" + elemType + ")"
+		}
+		return elemType + "[]"
+
+	case reflect.Map:
+		elemField.Type = t.Elem()
+		return "Record<" + toTsTypeName(t.Key(), prefix) + ", " + toTsFieldTypeName(elemField, prefix) + ">"
+	}
+
+	if isEnum {
+		if values, err := rpc.GetEnum(field); err == nil {
+			return toTsUnion(values)
+		}
+	}
+
+	if format.TsType != "" && t.Kind() == reflect.String {
+		return format.TsType
+	}
+
+	return toTsTypeName(field.Type, prefix)
+}
+
+func toTsEnumUnion(t reflect.Type) string {
+	values, _ := rpc.GetTypeEnum(t)
+
+	return toTsUnion(values)
+}
+
+func toTsUnion(values []interface{}) string {
+	if len(values) == 0 {
+		return "never"
+	}
+
+	res := make([]string, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			res[i] = "'" + strings.ReplaceAll(strings.ReplaceAll(s, "\\", "\\\\"), "'", "\\'") + "'"
+		} else {
+			res[i] = fmt.Sprint(v)
+		}
+	}
+
+	return strings.Join(res, " | ")
+}
+
 func addTsStructTypes(st reflect.Type, prefix string, m map[string]reflect.Type) {
 	if typesOverrides[st.PkgPath()+"."+st.Name()] != "" {
 		return
 	}
 
+	if isTsEnum(st) {
+		m[toTsTypeName(st, prefix)] = st
+		return
+	}
+
 	switch st.Kind() {
 	case reflect.Ptr, reflect.Slice, reflect.Array:
 		addTsStructTypes(st.Elem(), prefix, m)
@@ -179,19 +401,27 @@ var (
 		"time.Time": "string",
 	}
 
-	tsLibBody = `export class ApiError extends Error {
-  private readonly _code: string
+	tsLibBody = `export type ValidationError = {
+  path: string
+  json_pointer: string
+  rule: string
+  message: string
+  params?: Record<string, unknown>
+}
+
+export class ApiError<C extends string = string, D = unknown> extends Error {
+  private readonly _code: C
   private readonly _message: string
-  private readonly _data: unknown
+  private readonly _data: D
 
-  constructor(code: string, message: string, data: unknown) {
+  constructor(code: C, message: string, data: D) {
     super(message)
     this._code = code
     this._message = message
     this._data = data
   }
 
-  get code(): string {
+  get code(): C {
     return this._code
   }
 
@@ -199,7 +429,7 @@ var (
     return this._message
   }
 
-  get data(): unknown {
+  get data(): D {
     return this._data
   }
 }
@@ -237,6 +467,7 @@ export default class API {
         return new Promise<Response>((resolve, reject) => {
           switch (response.status) {
             case 200:
+            case 204:
               resolve(response)
               break
             case 400:
@@ -244,6 +475,17 @@ export default class API {
                 reject(new ApiError(err.code, err.message, err.data))
               })
               break
+            case 503:
+              if (response.headers.get('Content-Type')?.startsWith('application/json')) {
+                response.json().then(err => {
+                  reject(new ApiError(err.code, err.message, err.data))
+                })
+                break
+              }
+              response.text().then(text => {
+                reject(new Error(text || response.statusText))
+              })
+              break
             default:
               response.text().then(text => {
                 reject(new Error(text || response.statusText))
@@ -251,12 +493,18 @@ export default class API {
           }
         })
       })
-      .then((response) => response.json())
+      .then((response) => response.status === 204 ? undefined : response.json())
   }`
 )
 
 func checkContentType(st reflect.Type) string {
-	st = st.Elem()
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	if st.Kind() != reflect.Struct {
+		return "application/json"
+	}
+
 	ret := "application/json"
 	for i := 0; i < st.NumField(); i++ {
 		if st.Field(i).Type == reflect.TypeOf((*rpc.File)(nil)).Elem() {
@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+)
+
+type callContextKey struct{}
+
+type callContext struct {
+	request *http.Request
+	method  *MethodDesc
+	header  http.Header
+}
+
+func withCallContext(ctx context.Context, request *http.Request, method *MethodDesc) (context.Context, *callContext) {
+	cc := &callContext{
+		request: request,
+		method:  method,
+		header:  http.Header{},
+	}
+
+	return context.WithValue(ctx, callContextKey{}, cc), cc
+}
+
+func getCallContext(ctx context.Context) *callContext {
+	cc, _ := ctx.Value(callContextKey{}).(*callContext)
+	return cc
+}
+
+// RequestFromContext returns the HTTP request of the call or nil.
+func RequestFromContext(ctx context.Context) *http.Request {
+	if cc := getCallContext(ctx); cc != nil {
+		return cc.request
+	}
+
+	return nil
+}
+
+// MethodFromContext returns the description of the called method or nil.
+func MethodFromContext(ctx context.Context) *MethodDesc {
+	if cc := getCallContext(ctx); cc != nil {
+		return cc.method
+	}
+
+	return nil
+}
+
+// SetResponseHeader sets the HTTP response header. It does nothing outside of a call.
+func SetResponseHeader(ctx context.Context, key, value string) {
+	if cc := getCallContext(ctx); cc != nil {
+		cc.header.Set(key, value)
+	}
+}
+
+// AddResponseHeader adds the value to the HTTP response header. It does nothing outside of a call.
+func AddResponseHeader(ctx context.Context, key, value string) {
+	if cc := getCallContext(ctx); cc != nil {
+		cc.header.Add(key, value)
+	}
+}
+
+// SetCookie adds the Set-Cookie header to the HTTP response. It does nothing outside of a call.
+func SetCookie(ctx context.Context, cookie *http.Cookie) {
+	if v := cookie.String(); v != "" {
+		AddResponseHeader(ctx, "Set-Cookie", v)
+	}
+}
+
+// ResponseHeadersProvider is an optional Method interface to document the response headers set by the method.
+// The result maps the header name to the description.
+type ResponseHeadersProvider interface {
+	ResponseHeaders(ctx context.Context) map[string]string
+}
+
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		dst[key] = values
+	}
+}
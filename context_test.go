@@ -0,0 +1,52 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mSession "github.com/go-qbit/rpc/internal/test/method/session"
+)
+
+func TestRpc_ServeHTTP_Context(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mSession.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/session/v1", nil)
+	req.Header.Set("X-Client", "test client")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var data mSession.RespV1
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Client != "test client" || data.Path != "/session/v1" {
+		t.Fatalf("Invalid response %+v", data)
+	}
+
+	if id := resp.Header.Get("X-Session-Id"); id != "42" {
+		t.Fatalf("Invalid X-Session-Id header '%s', expected '42'", id)
+	}
+
+	if cookies := resp.Cookies(); len(cookies) != 1 || cookies[0].Name != "session" {
+		t.Fatalf("Invalid cookies %v", cookies)
+	}
+
+	headers := r.GetSwagger(context.Background()).Paths["/session/v1"].Post.Responses["200"].Headers
+	if headers["X-Session-Id"].Description != "The session ID" {
+		t.Fatalf("No documented response header")
+	}
+}
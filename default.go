@@ -0,0 +1,212 @@
+package rpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// defaultValues sets the default values of the struct fields to the request before decoding,
+// so the values present in the request win.
+type defaultValues struct {
+	fields []defaultField
+}
+
+type defaultField struct {
+	index  int
+	value  reflect.Value  // The parsed default:"..." tag, invalid if the field has no tag
+	nested *defaultValues // The defaults of the struct field
+}
+
+// GetDefault returns the value of the default:"..." tag of the field, false if there is no tag.
+// The slices values are comma separated, the strings are used as is, the rest values are parsed as JSON
+// or by encoding.TextUnmarshaler.
+func GetDefault(f reflect.StructField) (interface{}, bool, error) {
+	v, exists, err := parseDefault(f)
+	if !exists || err != nil {
+		return nil, exists, err
+	}
+
+	return v.Interface(), true, nil
+}
+
+func parseDefault(f reflect.StructField) (reflect.Value, bool, error) {
+	tag, exists := f.Tag.Lookup("default")
+	if !exists {
+		return reflect.Value{}, false, nil
+	}
+
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		res := reflect.MakeSlice(t, 0, 0)
+		if tag == "" {
+			return res, true, nil
+		}
+
+		for _, item := range strings.Split(tag, ",") {
+			v, err := parseDefaultValue(t.Elem(), strings.TrimSpace(item))
+			if err != nil {
+				return reflect.Value{}, true, err
+			}
+			res = reflect.Append(res, v)
+		}
+
+		return res, true, nil
+
+	case reflect.Struct, reflect.Map, reflect.Array, reflect.Interface:
+		if !reflect.PtrTo(t).Implements(textUnmarshalerType) {
+			return reflect.Value{}, true, fmt.Errorf("default is not supported for %s", t.Kind())
+		}
+	}
+
+	v, err := parseDefaultValue(t, tag)
+	return v, true, err
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func parseDefaultValue(t reflect.Type, s string) (reflect.Value, error) {
+	res := reflect.New(t)
+
+	switch {
+	case res.Type().Implements(textUnmarshalerType):
+		if err := res.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid default %s: %w", s, err)
+		}
+
+	case t.Kind() == reflect.String:
+		res.Elem().SetString(s)
+
+	default:
+		if err := json.Unmarshal([]byte(s), res.Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid default %s: %w", s, err)
+		}
+	}
+
+	return res.Elem(), nil
+}
+
+// getDefaults returns the defaults of the struct type fields, nil if there are no defaults.
+// The fields of the nested structs are set only if the struct is not a pointer,
+// so the absent pointers stay nil.
+func getDefaults(t reflect.Type, curPath string, visited map[reflect.Type]bool) (*defaultValues, []error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || visited[t] {
+		return nil, nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	res := &defaultValues{}
+	var problems []error
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		df := defaultField{index: i}
+
+		value, exists, err := parseDefault(f)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s/%s: %w", curPath, f.Name, err))
+			continue
+		}
+		if exists {
+			df.value = value
+		}
+
+		if f.Type.Kind() == reflect.Struct && !exists {
+			var nestedProblems []error
+			df.nested, nestedProblems = getDefaults(f.Type, curPath+"/"+f.Name, visited)
+			problems = append(problems, nestedProblems...)
+		}
+
+		if df.value.IsValid() || df.nested != nil {
+			res.fields = append(res.fields, df)
+		}
+	}
+
+	if len(res.fields) == 0 {
+		return nil, problems
+	}
+
+	return res, problems
+}
+
+// checkDefaults returns the errors of the default tags of all the struct fields reachable from the type,
+// e.g. the items of the slices which are not set by getDefaults, but are shown in the documentation.
+func checkDefaults(t reflect.Type, curPath string, visited map[reflect.Type]bool) []error {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return checkDefaults(t.Elem(), curPath, visited)
+
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return checkDefaults(t.Elem(), curPath+"[]", visited)
+
+	case reflect.Struct:
+		if visited[t] {
+			return nil
+		}
+		visited[t] = true
+
+		var problems []error
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			if _, _, err := parseDefault(f); err != nil {
+				problems = append(problems, fmt.Errorf("%s/%s: %w", curPath, f.Name, err))
+				continue
+			}
+
+			problems = append(problems, checkDefaults(f.Type, curPath+"/"+f.Name, visited)...)
+		}
+
+		return problems
+	}
+
+	return nil
+}
+
+// apply sets the defaults to the struct value.
+func (d *defaultValues) apply(v reflect.Value) {
+	if d == nil {
+		return
+	}
+
+	for _, df := range d.fields {
+		f := v.Field(df.index)
+
+		if df.nested != nil {
+			df.nested.apply(f)
+			continue
+		}
+
+		value := df.value
+		if value.Kind() == reflect.Slice { // The decoder reuses the slice, so the default must not be shared
+			value = reflect.AppendSlice(reflect.MakeSlice(value.Type(), 0, value.Len()), value)
+		}
+
+		if f.Kind() == reflect.Ptr {
+			ptr := reflect.New(f.Type().Elem())
+			ptr.Elem().Set(value)
+			value = ptr
+		}
+
+		f.Set(value)
+	}
+}
@@ -0,0 +1,155 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-qbit/rpc"
+	mDefaults "github.com/go-qbit/rpc/internal/test/method/defaults"
+	"github.com/go-qbit/rpc/rpctest"
+)
+
+func TestRpc_ServeHTTP_Defaults(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mDefaults.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	offset := 0
+	expected := mDefaults.ReqV1{
+		Limit:   20,
+		Offset:  &offset,
+		Sort:    "name",
+		Desc:    true,
+		Ratio:   0.5,
+		Fields:  []string{"id", "name"},
+		Since:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Filter:  mDefaults.FilterV1{Status: "active", Ids: []int{1, 2}},
+		Timeout: 30,
+	}
+
+	for body, check := range map[string]func(resp *mDefaults.ReqV1){
+		`{}`: func(resp *mDefaults.ReqV1) {
+			if !reflect.DeepEqual(*resp, expected) {
+				t.Fatalf("Invalid defaults %+v, expected %+v", *resp, expected)
+			}
+		},
+		`{"limit": 50, "fields": ["a"], "filter": {"ids": [3, 4, 5]}, "options": {}}`: func(resp *mDefaults.ReqV1) {
+			if resp.Limit != 50 || !reflect.DeepEqual(resp.Fields, []string{"a"}) || resp.Sort != "name" {
+				t.Fatalf("Invalid request %+v", *resp)
+			}
+			if !reflect.DeepEqual(resp.Filter, mDefaults.FilterV1{Status: "active", Ids: []int{3, 4, 5}}) {
+				t.Fatalf("Invalid filter %+v", resp.Filter)
+			}
+			if resp.Options == nil || resp.Options.Status != "" {
+				t.Fatalf("Invalid options %+v", resp.Options)
+			}
+		},
+		`{"offset": null, "sort": "date", "desc": false}`: func(resp *mDefaults.ReqV1) {
+			if resp.Offset != nil || resp.Sort != "date" || resp.Desc {
+				t.Fatalf("Invalid request %+v", *resp)
+			}
+		},
+	} {
+		httpResp, err := srv.Post("/defaults/v1", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := httpResp.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		var resp mDefaults.ReqV1
+		if err := json.Unmarshal(httpResp.Body, &resp); err != nil {
+			t.Fatal(err)
+		}
+		check(&resp)
+	}
+
+	// The defaults are not shared between the calls
+	for _, ids := range [][]int{{7, 8}, {9}} {
+		httpResp, err := srv.PostJSON("/defaults/v1", map[string]interface{}{"filter": map[string]interface{}{"ids": ids}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := httpResp.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := rpctest.Call[struct{}, mDefaults.ReqV1](srv, "/defaults/v1", &struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.Filter.Ids, []int{1, 2}) || !reflect.DeepEqual(resp.Fields, []string{"id", "name"}) {
+		t.Fatalf("Invalid defaults %+v", *resp)
+	}
+}
+
+func TestRpc_GetSwagger_Defaults(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mDefaults.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(r.GetSwagger(context.Background()).Components.Schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		`"limit":{"description":"Page size","type":"integer","format":"int64","maximum":100,"default":20}`,
+		`"desc":{"type":"boolean","default":true}`,
+		`"fields":{"type":"array","items":{"type":"string"},"default":["id","name"]}`,
+		`"since":{"$ref":"#/components/schemas/time_time","default":"2024-01-01T00:00:00Z"}`,
+		`"ids":{"type":"array","items":{"type":"integer","format":"int64"},"default":[1,2]}`,
+	} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("The schema %s does not contain %s", data, s)
+		}
+	}
+}
+
+func TestRegisterMethod_InvalidDefault(t *testing.T) {
+	r := rpc.New("")
+	err := rpc.Handle(r, "/defaults/v2", func(ctx context.Context, req *struct {
+		Limit int               `json:"limit" default:"many"`
+		Tags  map[string]string `json:"tags" default:"a"`
+	}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid default many") || !strings.Contains(err.Error(), "default is not supported for map") {
+		t.Fatalf("Invalid error %v", err)
+	}
+}
+
+func TestRegisterMethod_InvalidResponseDefault(t *testing.T) {
+	type item struct {
+		Size int `json:"size" default:"big"`
+	}
+
+	r := rpc.New("")
+	err := rpc.Handle(r, "/defaults/v3", func(ctx context.Context, req *struct {
+		Items []item `json:"items"`
+	}) (*struct {
+		X int `json:"x" default:"abc"`
+	}, error) {
+		return nil, nil
+	})
+	if err == nil ||
+		!strings.Contains(err.Error(), "response/X: invalid default abc") ||
+		!strings.Contains(err.Error(), "request/Items[]/Size: invalid default big") {
+		t.Fatalf("Invalid error %v", err)
+	}
+
+	if r.GetMethod("/defaults/v3") != nil {
+		t.Fatal("The method with the invalid default is registered")
+	}
+	_ = r.GetSwagger(context.Background())
+}
@@ -11,9 +11,43 @@
 //
 // The fields validators
 //
-// minimum: TBD
+// minimum, maximum: the inclusive bounds of the int, uint and float fields.
 //
-// maximum: TBD
+// exclusiveMinimum, exclusiveMaximum: the exclusive bounds of the numeric fields.
 //
-// pattern: TBD
+// multipleOf: the positive number the numeric field value must be a multiple of.
+//
+// pattern: the regular expression the string field must match.
+//
+// minLength, maxLength: the bounds of the string length in characters (runes).
+//
+// minItems, maxItems: the bounds of the slice length.
+//
+// uniqueItems: "true" forbids the equal items in the slice or array.
+//
+// The custom validators of the tags are added by RegisterValidator.
+//
+// The tags of the slice, array or map field not used by the container itself are applied to its items,
+// e.g. pattern of []string checks each string. The map keys are checked by the tags with the key prefix,
+// e.g. keyPattern.
+//
+// required: "true" makes the JSON key of the field mandatory, the other fields are optional.
+//
+// format: the string format, one of email, uuid, date-time, date, uri, hostname, ipv4 and ipv6.
+// More formats can be added by RegisterFormat.
+//
+// enum: the comma separated allowed values of the string or numeric field. The named types can implement
+// EnumProvider instead.
+//
+// default: the value set to the field before decoding the request, so it's used if the field is absent.
+// The slices values are comma separated. The fields of the nested structs have their defaults unless
+// the struct is a pointer.
+//
+// All the failed fields are reported by the VALIDATION_ERROR error with the list of ValidationError as the data.
+// The values of the wrong JSON types are reported with the type rule. INVALID_JSON is returned only
+// if the request is not valid JSON.
+//
+// The messages are translated by the Translator set by WithTranslator to the language of WithLanguage
+// or Accept-Language. The validation messages are keyed by the rule, see DefaultMessages for the templates,
+// the business errors messages are keyed by the error code.
 package rpc
@@ -0,0 +1,179 @@
+package rpc
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-qbit/rpc/openapi"
+)
+
+// EnumProvider is implemented by the named types having the fixed set of values.
+// The enum tag of the field, e.g. enum:"a,b,c", overrides the values.
+type EnumProvider interface {
+	Enum() []any
+}
+
+var enumProviderType = reflect.TypeOf((*EnumProvider)(nil)).Elem()
+
+// GetEnum returns the allowed values of the field set by the enum tag or by the EnumProvider field type.
+// It returns nil if any value is allowed.
+func GetEnum(f reflect.StructField) ([]interface{}, error) {
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	tag, exists := f.Tag.Lookup("enum")
+	if !exists {
+		return GetTypeEnum(t)
+	}
+
+	var res []interface{}
+	for _, s := range strings.Split(tag, ",") {
+		s = strings.TrimSpace(s)
+
+		var (
+			val interface{}
+			err error
+		)
+		switch t.Kind() {
+		case reflect.String:
+			val = s
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			val, err = strconv.ParseInt(s, 10, 64)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			val, err = strconv.ParseUint(s, 10, 64)
+		case reflect.Float32:
+			var f float64
+			f, err = strconv.ParseFloat(s, 32)
+			val = float32(f)
+		case reflect.Float64:
+			val, err = strconv.ParseFloat(s, 64)
+		default:
+			return nil, fmt.Errorf("enum is not supported for %s", t.Kind())
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, val)
+	}
+
+	return res, nil
+}
+
+// GetTypeEnum returns the values of the type implementing EnumProvider, nil for the other types.
+// The values are converted to string, int64, uint64, float32 or float64 according to the type kind.
+func GetTypeEnum(t reflect.Type) ([]interface{}, error) {
+	var provider EnumProvider
+	switch {
+	case t.Implements(enumProviderType):
+		provider = reflect.Zero(t).Interface().(EnumProvider)
+	case reflect.PtrTo(t).Implements(enumProviderType):
+		provider = reflect.New(t).Interface().(EnumProvider)
+	default:
+		return nil, nil
+	}
+
+	var res []interface{}
+	for _, v := range provider.Enum() {
+		val, ok := enumValue(reflect.ValueOf(v), t.Kind())
+		if !ok {
+			return nil, fmt.Errorf("enum value %v of %s is not %s", v, t, t.Kind())
+		}
+		res = append(res, val)
+	}
+
+	return res, nil
+}
+
+// enumValue converts the value to the comparable form for the kind.
+func enumValue(v reflect.Value, kind reflect.Kind) (interface{}, bool) {
+	switch kind {
+	case reflect.String:
+		if v.Kind() == reflect.String {
+			return v.String(), true
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case v.CanInt():
+			return v.Int(), true
+		case v.CanUint() && v.Uint() <= math.MaxInt64:
+			return int64(v.Uint()), true
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch {
+		case v.CanUint():
+			return v.Uint(), true
+		case v.CanInt() && v.Int() >= 0:
+			return uint64(v.Int()), true
+		}
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch {
+		case v.CanFloat():
+			f = v.Float()
+		case v.CanInt():
+			f = float64(v.Int())
+		case v.CanUint():
+			f = float64(v.Uint())
+		default:
+			return nil, false
+		}
+
+		if kind == reflect.Float32 { // Compared at the field precision
+			return float32(f), true
+		}
+		return f, true
+	}
+
+	return nil, false
+}
+
+// vEnum is not created by the tag only, the EnumProvider types are checked without the enum tag.
+type vEnum struct{}
+
+func (v vEnum) Tag() string { return "enum" }
+
+func (v vEnum) ToSwaggerSchema(f reflect.StructField, schema *openapi.Schema) error {
+	values, err := GetEnum(f)
+	if err != nil {
+		return err
+	}
+
+	schema.Enum = values
+
+	return nil
+}
+
+func (v vEnum) GetValidateFunc(f reflect.StructField) (ValidateFunc, error) {
+	values, err := GetEnum(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if values == nil {
+		return nil, nil
+	}
+
+	allowed := make(map[interface{}]struct{}, len(values))
+	for _, val := range values {
+		allowed[val] = struct{}{}
+	}
+
+	return func(v interface{}) error {
+		rv := reflect.ValueOf(v)
+		val, _ := enumValue(rv, rv.Kind())
+		if _, ok := allowed[val]; !ok {
+			return ruleError("enum", map[string]interface{}{"value": v, "enum": values})
+		}
+
+		return nil
+	}, nil
+}
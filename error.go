@@ -12,6 +12,9 @@ type Error struct {
 
 type ErrorFunc func(message string, data ...interface{}) *Error
 
+// ErrorFuncT is the ErrorFunc with the typed data, the data type is documented for the error code.
+type ErrorFuncT[T any] func(message string, data T) *Error
+
 func (e *Error) Error() string {
 	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"mime/multipart"
 	"os"
+	"reflect"
 )
 
 var (
@@ -18,6 +19,27 @@ type File interface {
 	Size() int64
 }
 
+var fileType = reflect.TypeOf((*File)(nil)).Elem()
+
+// isMultipart reports whether the request type has File fields, so it is sent as multipart/form-data.
+func isMultipart(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type == fileType {
+			return true
+		}
+	}
+
+	return false
+}
+
 type buffer struct {
 	bytes.Buffer
 	filename string
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-qbit/rpc/openapi"
+)
+
+// Format is the named string format checked by the format tag, e.g. format:"email".
+// The empty strings are not checked, minLength:"1" forbids them.
+type Format struct {
+	Validate func(s string) bool
+	TsType   string // The TypeScript type of the fields, string if empty
+}
+
+var (
+	formatsMtx sync.RWMutex
+	formats    = map[string]Format{
+		"email":     {Validate: isEmail},
+		"uuid":      {Validate: regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`).MatchString},
+		"date-time": {Validate: isTime(time.RFC3339)},
+		"date":      {Validate: isTime("2006-01-02")},
+		"uri":       {Validate: isURI},
+		"hostname":  {Validate: isHostname},
+		"ipv4":      {Validate: isIP(false)},
+		"ipv6":      {Validate: isIP(true)},
+	}
+
+	reHostnameLabel = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+)
+
+// RegisterFormat adds the named format or replaces the existing one.
+// The formats must be registered before the methods using them.
+func RegisterFormat(name string, f Format) {
+	formatsMtx.Lock()
+	defer formatsMtx.Unlock()
+
+	formats[name] = f
+}
+
+func GetFormat(name string) (Format, bool) {
+	formatsMtx.RLock()
+	defer formatsMtx.RUnlock()
+
+	f, exists := formats[name]
+	return f, exists
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+func isTime(layout string) func(string) bool {
+	return func(s string) bool {
+		_, err := time.Parse(layout, s)
+		return err == nil
+	}
+}
+
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
+
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if !reHostnameLabel.MatchString(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isIP(v6 bool) func(string) bool {
+	return func(s string) bool {
+		return net.ParseIP(s) != nil && strings.Contains(s, ":") == v6
+	}
+}
+
+func formatValidator(name string, f reflect.StructField) (ValidateFunc, SchemaFunc, error) {
+	format, exists := GetFormat(name)
+	if !exists {
+		return nil, nil, fmt.Errorf("unknown format %s", name)
+	}
+
+	validate := func(v interface{}) error {
+		val := reflect.ValueOf(v).String()
+		if val != "" && !format.Validate(val) {
+			return ruleError("format", map[string]interface{}{"value": val, "format": name})
+		}
+
+		return nil
+	}
+
+	return validate, func(schema *openapi.Schema) { schema.Format = name }, nil
+}
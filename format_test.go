@@ -0,0 +1,116 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mValidate "github.com/go-qbit/rpc/internal/test/method/validate"
+	"github.com/go-qbit/rpc/rpctest"
+)
+
+func TestRpc_ServeHTTP_Formats(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	valid := map[string]interface{}{
+		"email":     "user@example.com",
+		"uuid":      "123e4567-e89b-12d3-a456-426614174000",
+		"date_time": "2024-01-02T15:04:05Z",
+		"date":      "2024-01-02",
+		"uri":       "https://example.com/path?q=1",
+		"hostname":  "api.example.com",
+		"ipv4":      "192.168.0.1",
+		"ipv6":      "::1",
+		"emails":    []string{"a@example.com"},
+	}
+
+	for _, tc := range []struct {
+		field string
+		value interface{}
+	}{
+		{"email", "User <user@example.com>"},
+		{"email", "user"},
+		{"uuid", "123e4567-e89b-12d3-a456"},
+		{"date_time", "2024-01-02 15:04:05"},
+		{"date", "02.01.2024"},
+		{"uri", "example.com"},
+		{"hostname", "-example.com"},
+		{"ipv4", "::1"},
+		{"ipv6", "192.168.0.1"},
+		{"emails", []string{"a@example.com", "b"}},
+	} {
+		t.Run(tc.field, func(t *testing.T) {
+			resp, err := srv.PostJSON("/validate/v4", valid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Status != http.StatusNoContent {
+				t.Fatalf("Invalid status code = %d, expected 204. Data: '%s'", resp.Status, resp.Body)
+			}
+
+			req := map[string]interface{}{}
+			for k, v := range valid {
+				req[k] = v
+			}
+			req[tc.field] = tc.value
+
+			resp, err = srv.PostJSON("/validate/v4", req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rpctest.AssertError(t, resp.Err(), "VALIDATION_ERROR")
+		})
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	rpc.RegisterFormat("test-currency", rpc.Format{
+		Validate: func(s string) bool { return s == "USD" || s == "EUR" },
+		TsType:   "'USD' | 'EUR'",
+	})
+
+	r := rpc.New("")
+	err := rpc.Handle(r, "/currency/v1", func(ctx context.Context, req *struct {
+		Currency string `json:"currency" format:"test-currency"`
+	}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := srv.PostJSON("/currency/v1", map[string]string{"currency": "RUB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpctest.AssertError(t, resp.Err(), "VALIDATION_ERROR")
+
+	data, err := json.Marshal(r.GetSwagger(context.Background()).Components.Schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"currency":{"type":"string","format":"test-currency"}`) {
+		t.Fatalf("No format in the schema %s", data)
+	}
+
+	err = rpc.Handle(r, "/unknown/v1", func(ctx context.Context, req *struct {
+		Value string `json:"value" format:"test-unknown"`
+	}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "unknown format test-unknown") {
+		t.Fatalf("Invalid error %v", err)
+	}
+}
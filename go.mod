@@ -1,3 +1,3 @@
 module github.com/go-qbit/rpc
 
-go 1.16
+go 1.18
@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// funcMethod is the Method of the function registered by Handle.
+type funcMethod struct {
+	path        string
+	caption     string
+	description string
+}
+
+func (m *funcMethod) Caption(context.Context) string     { return m.caption }
+func (m *funcMethod) Description(context.Context) string { return m.description }
+
+type handleOpts struct {
+	caption     string
+	description string
+	errors      interface{}
+	limit       *ConcurrencyLimit
+	replace     bool
+	visibility  Visibility
+}
+
+type HandleOptsFunc func(*handleOpts)
+
+func Caption(caption string) HandleOptsFunc {
+	return func(opts *handleOpts) {
+		opts.caption = caption
+	}
+}
+
+func Description(description string) HandleOptsFunc {
+	return func(opts *handleOpts) {
+		opts.description = description
+	}
+}
+
+// Errors binds the pointer to the structure with the ErrorFunc fields, like the ErrorsVn method does.
+func Errors(errors interface{}) HandleOptsFunc {
+	return func(opts *handleOpts) {
+		opts.errors = errors
+	}
+}
+
+func Limit(limit ConcurrencyLimit) HandleOptsFunc {
+	return func(opts *handleOpts) {
+		opts.limit = &limit
+	}
+}
+
+func SetVisibility(visibility Visibility) HandleOptsFunc {
+	return func(opts *handleOpts) {
+		opts.visibility = visibility
+	}
+}
+
+// Replace allows to replace the function previously registered with the same path.
+func Replace() HandleOptsFunc {
+	return func(opts *handleOpts) {
+		opts.replace = true
+	}
+}
+
+// Handle registers the function as the method with the full path including the version, e.g. "/users/get/v1".
+// The request and response are processed the same way as for the methods registered by RegisterMethod.
+func Handle[Req, Resp any](r *Rpc, path string, f func(context.Context, *Req) (*Resp, error), options ...HandleOptsFunc) error {
+	opts := handleOpts{}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	path = "/" + strings.Trim(path, "/")
+	method := &funcMethod{
+		path:        path,
+		caption:     opts.caption,
+		description: opts.description,
+	}
+
+	fn := reflect.ValueOf(func(_ *funcMethod, ctx context.Context, req *Req) (*Resp, error) {
+		return f(ctx, req)
+	})
+
+	var mds []*MethodDesc
+	md, problems := newMethodDesc(path, path, method, fn, fn.Type().In(2), fn.Type().Out(0))
+	if md != nil {
+		if opts.limit != nil {
+			md.limiter = newLimiter(*opts.limit)
+		}
+		md.Visibility = opts.visibility
+		mds = append(mds, md)
+	}
+
+	return r.addDescs(method, mds, problems, opts.replace, func(map[string]*MethodDesc) ([]errorBinding, []error) {
+		if opts.errors == nil || md == nil {
+			return nil, nil
+		}
+
+		var bindings []errorBinding
+		problems := bindErrorsStruct(fmt.Sprintf("%s errors", path), reflect.ValueOf(opts.errors), md.Errors, md.ErrorTypes, &bindings)
+
+		return bindings, problems
+	})
+}
@@ -0,0 +1,80 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+)
+
+type createUserReq struct {
+	Name string `json:"name" pattern:"^\\w+$"`
+}
+
+type createUserResp struct {
+	Id int `json:"id"`
+}
+
+var createUserErrors struct {
+	AlreadyExists rpc.ErrorFunc `desc:"The user already exists"`
+}
+
+func TestHandle(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+
+	if err := rpc.Handle(r, "/users/create/v1", func(ctx context.Context, req *createUserReq) (*createUserResp, error) {
+		if req.Name == "admin" {
+			return nil, createUserErrors.AlreadyExists("admin exists")
+		}
+		return &createUserResp{Id: 1}, nil
+	},
+		rpc.Caption("Create user"),
+		rpc.Errors(&createUserErrors),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rpc.Handle(r, "/users/create/v1", func(ctx context.Context, req *createUserReq) (*createUserResp, error) {
+		return nil, nil
+	}); err == nil {
+		t.Fatalf("Expected the duplicate path error")
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{`{"name": "user"}`, 200, ""},
+		{`{"name": "admin"}`, 400, "AlreadyExists"},
+		{`{"name": "-"}`, 400, "VALIDATION_ERROR"},
+	} {
+		resp, err := srv.Client().Post(srv.URL+"/users/create/v1", "application/json", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var rpcErr rpc.Error
+		_ = json.NewDecoder(resp.Body).Decode(&rpcErr)
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.status || rpcErr.Code != tc.code {
+			t.Fatalf("Invalid response for %s: %d '%s', expected %d '%s'", tc.body, resp.StatusCode, rpcErr.Code, tc.status, tc.code)
+		}
+	}
+
+	swagger := r.GetSwagger(context.Background())
+	if summary := swagger.Paths["/users/create/v1"].Post.Summary; summary != "Create user" {
+		t.Fatalf("Invalid summary '%s', expected 'Create user'", summary)
+	}
+
+	if !strings.Contains(swagger.Paths["/users/create/v1"].Post.Responses["400"].Description, "AlreadyExists") {
+		t.Fatalf("No business error in the OpenAPI")
+	}
+}
@@ -0,0 +1,248 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Translator returns the message of the key in the language, false if there is no translation.
+// The key is the validation rule for the VALIDATION_ERROR entries, e.g. "minimum", and the error code for the rest errors.
+type Translator interface {
+	Translate(lang, key string, params map[string]interface{}) (string, bool)
+}
+
+// defaultMessages are the English templates of the built-in rules messages.
+var defaultMessages = map[string]string{
+	"minimum":          "{value} is less than required minimum {minimum}",
+	"maximum":          "{value} is greater than required maximum {maximum}",
+	"exclusiveMinimum": "{value} is not greater than exclusive minimum {exclusiveMinimum}",
+	"exclusiveMaximum": "{value} is not less than exclusive maximum {exclusiveMaximum}",
+	"multipleOf":       "{value} is not a multiple of {multipleOf}",
+	"minLength":        "has {value} characters, less than required minLength {minLength}",
+	"maxLength":        "has {value} characters, more than required maxLength {maxLength}",
+	"minItems":         "has {value} items, less than required minItems {minItems}",
+	"maxItems":         "has {value} items, more than required maxItems {maxItems}",
+	"uniqueItems":      "has the duplicate item at {index}",
+	"pattern":          "{value} does not match the pattern {pattern}",
+	"format":           "{value} is not a valid {format}",
+	"enum":             "{value} is not one of {enum}",
+	"required":         "is required",
+	"type":             "is {value}, expected {type}",
+}
+
+// DefaultMessages returns the English templates of the built-in rules messages by the rule name,
+// e.g. "minimum": "{value} is less than required minimum {minimum}".
+func DefaultMessages() map[string]string {
+	res := make(map[string]string, len(defaultMessages))
+	for rule, template := range defaultMessages {
+		res[rule] = template
+	}
+
+	return res
+}
+
+func ruleError(rule string, params map[string]interface{}) *RuleError {
+	return &RuleError{Message: FormatMessage(defaultMessages[rule], params), Params: params}
+}
+
+var placeholderRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// FormatMessage replaces the {name} placeholders of the template by the params.
+// The long value parameter, i.e. the user input, is truncated, the unknown placeholders are kept as is.
+func FormatMessage(template string, params map[string]interface{}) string {
+	return placeholderRe.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, exists := params[name]
+		if !exists {
+			return placeholder
+		}
+
+		s := fmt.Sprint(value)
+		if runes := []rune(s); name == "value" && len(runes) > 25 {
+			s = string(runes[:22]) + "..."
+		}
+
+		return s
+	})
+}
+
+// Catalog is the Translator with the message templates by the language and the key.
+// The templates use the FormatMessage placeholders. The regional language falls back to the base one,
+// e.g. "pt-BR" to "pt".
+type Catalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{messages: map[string]map[string]string{}}
+}
+
+// Add adds the templates of the language by the key, the existing keys are replaced.
+func (c *Catalog) Add(lang string, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lang = strings.ToLower(lang)
+	if c.messages[lang] == nil {
+		c.messages[lang] = map[string]string{}
+	}
+	for key, template := range messages {
+		c.messages[lang][key] = template
+	}
+}
+
+// LoadFS adds the JSON files matching the pattern, e.g. "locales/*.json". Each file is the object
+// of the templates by the key, the file name without the extension is the language, e.g. "de.json".
+// It's intended for the catalogs embedded by embed.FS.
+func (c *Catalog) LoadFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		base := path.Base(name)
+		c.Add(strings.TrimSuffix(base, path.Ext(base)), messages)
+	}
+
+	return nil
+}
+
+func (c *Catalog) Translate(lang, key string, params map[string]interface{}) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	lang = strings.ToLower(lang)
+	for {
+		if template, exists := c.messages[lang][key]; exists {
+			return FormatMessage(template, params), true
+		}
+
+		i := strings.LastIndexByte(lang, '-')
+		if i < 0 {
+			return "", false
+		}
+		lang = lang[:i]
+	}
+}
+
+type languageContextKey struct{}
+
+// WithLanguage returns the context with the language of the messages. It overrides the Accept-Language header.
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageContextKey{}, lang)
+}
+
+// Languages returns the languages of the messages in the order of preference:
+// the language set by WithLanguage or the Accept-Language header of the call.
+func Languages(ctx context.Context) []string {
+	if lang, ok := ctx.Value(languageContextKey{}).(string); ok {
+		return []string{lang}
+	}
+
+	if request := RequestFromContext(ctx); request != nil {
+		return parseAcceptLanguage(request.Header.Get("Accept-Language"))
+	}
+
+	return nil
+}
+
+// parseAcceptLanguage returns the languages of the header sorted by the quality, e.g. "de-CH, fr;q=0.9".
+func parseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		tag, quality := strings.TrimSpace(part), 1.0
+		if i := strings.IndexByte(tag, ';'); i >= 0 {
+			if q := strings.TrimSpace(tag[i+1:]); strings.HasPrefix(q, "q=") {
+				var err error
+				if quality, err = strconv.ParseFloat(q[2:], 64); err != nil {
+					continue
+				}
+			}
+			tag = strings.TrimSpace(tag[:i])
+		}
+
+		if tag == "" || tag == "*" || quality <= 0 {
+			continue
+		}
+		languages = append(languages, language{tag, quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].quality > languages[j].quality })
+
+	res := make([]string, len(languages))
+	for i, l := range languages {
+		res[i] = l.tag
+	}
+
+	return res
+}
+
+func translate(ctx context.Context, t Translator, key string, params map[string]interface{}) (string, bool) {
+	for _, lang := range Languages(ctx) {
+		if msg, ok := t.Translate(lang, key, params); ok {
+			return msg, true
+		}
+	}
+
+	return "", false
+}
+
+// translateError returns the copy of the error with the translated messages. The VALIDATION_ERROR entries
+// are translated by the rule, the rest errors by the code with the message and the data fields as the params.
+func translateError(ctx context.Context, t Translator, err *Error) *Error {
+	if errs, ok := err.Data.([]ValidationError); ok && err.Code == ErrorCodeValidation {
+		translated := make([]ValidationError, len(errs))
+		for i, e := range errs {
+			translated[i] = e
+			if msg, ok := translate(ctx, t, e.Rule, e.Params); ok {
+				translated[i].Message = msg
+			}
+		}
+
+		return newValidationError(translated)
+	}
+
+	var params map[string]interface{}
+	if data, e := json.Marshal(err.Data); e == nil {
+		_ = json.Unmarshal(data, &params) // Only the objects data fields are used
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	params["message"] = err.Message
+
+	msg, ok := translate(ctx, t, err.Code, params)
+	if !ok {
+		return err
+	}
+
+	res := *err
+	res.Message = msg
+
+	return &res
+}
@@ -0,0 +1,113 @@
+package rpc_test
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mHello "github.com/go-qbit/rpc/internal/test/method/hello"
+	"github.com/go-qbit/rpc/rpctest"
+)
+
+//go:embed testdata/locales/*.json
+var locales embed.FS
+
+func TestRpc_ServeHTTP_Translator(t *testing.T) {
+	catalog := rpc.NewCatalog()
+	if err := catalog.LoadFS(locales, "testdata/locales/*.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method", rpc.WithTranslator(catalog))
+	if err := r.RegisterMethod(mHello.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	invalid := `{"int_param": 150, "str_param": "s", "struct_param": {"f1": 0}}`
+
+	for _, tc := range []struct {
+		acceptLanguage string
+		body           string
+		code           string
+		message        string
+	}{
+		{"", invalid, "VALIDATION_ERROR", "str_param: s does not match the pattern .{2,}; struct_param.f1: 0 is less than required minimum 1"},
+		{"fr, de-CH;q=0.8", invalid, "VALIDATION_ERROR", "str_param: s entspricht nicht dem Muster .{2,}; struct_param.f1: 0 ist kleiner als das Minimum 1"},
+		{"de;q=0.5, ru", invalid, "VALIDATION_ERROR", "str_param: s entspricht nicht dem Muster .{2,}; struct_param.f1: 0 меньше минимума 1"},
+		{"ru-RU", `{"int_param": 150, "str_param": "str", "struct_param": {"f1": 1}, "with_err": true}`, "Error1", "Ошибка: test"},
+		{"de", `{"int_param": 150, "str_param": "str", "struct_param": {"f1": 1}, "with_err": true}`, "Error1", "test"},
+		{"de", `{"int_param": 150, "str_param": "str", "struct_param": {"f1": 1}, "with_typed_err": true}`, "Error4", "Feld with_typed_err ist ungültig: test"},
+	} {
+		request, err := http.NewRequest(http.MethodPost, srv.URL+"/hello/v1", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept-Language", tc.acceptLanguage)
+
+		httpResp, err := srv.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var rpcErr rpc.Error
+		err = json.NewDecoder(httpResp.Body).Decode(&rpcErr)
+		httpResp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rpcErr.Code != tc.code || rpcErr.Message != tc.message {
+			t.Fatalf("Invalid error [%s] '%s' for %s, expected [%s] '%s'", rpcErr.Code, rpcErr.Message, tc.acceptLanguage, tc.code, tc.message)
+		}
+	}
+
+	_, err := r.Invoke(rpc.WithLanguage(context.Background(), "de"), "/hello/v1", []byte(invalid))
+	errs := rpctest.ValidationErrors(err)
+	if len(errs) != 2 || errs[1].Message != "0 ist kleiner als das Minimum 1" {
+		t.Fatalf("Invalid validation errors %+v", errs)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	catalog := rpc.NewCatalog()
+	catalog.Add("pt", map[string]string{"required": "é obrigatório"})
+	catalog.Add("pt-BR", map[string]string{"minimum": "{value} é menor que {minimum}, {unknown}"})
+
+	for _, tc := range []struct {
+		lang, key, message string
+		ok                 bool
+	}{
+		{"pt-BR", "minimum", "1 é menor que 10, {unknown}", true},
+		{"PT-br", "required", "é obrigatório", true},
+		{"pt", "minimum", "", false},
+		{"en", "required", "", false},
+	} {
+		msg, ok := catalog.Translate(tc.lang, tc.key, map[string]interface{}{"value": 1, "minimum": 10})
+		if msg != tc.message || ok != tc.ok {
+			t.Fatalf("Invalid translation of %s to %s: '%s' %v, expected '%s' %v", tc.key, tc.lang, msg, ok, tc.message, tc.ok)
+		}
+	}
+
+	if msg := rpc.FormatMessage(rpc.DefaultMessages()["pattern"], map[string]interface{}{"value": "abcdefghijklmnopqrstuvwxyz", "pattern": "^a$"}); msg != "abcdefghijklmnopqrstuv... does not match the pattern ^a$" {
+		t.Fatalf("Invalid message '%s'", msg)
+	}
+
+	// Only the user input is truncated
+	if msg := rpc.FormatMessage(rpc.DefaultMessages()["pattern"], map[string]interface{}{"value": "a", "pattern": "^[a-z]+@[a-z]+\\.[a-z]{2,}$"}); msg != "a does not match the pattern ^[a-z]+@[a-z]+\\.[a-z]{2,}$" {
+		t.Fatalf("Invalid message '%s'", msg)
+	}
+
+	catalog.Add("ru", map[string]string{"Error1": "Ошибка: {message}"})
+	message := "The user account is blocked for security reasons"
+	if msg, _ := catalog.Translate("ru", "Error1", map[string]interface{}{"message": message}); msg != "Ошибка: "+message {
+		t.Fatalf("Invalid message '%s'", msg)
+	}
+}
@@ -0,0 +1,46 @@
+// Package broken contains a method with the registration problems.
+package broken
+
+import (
+	"context"
+
+	"github.com/go-qbit/rpc"
+)
+
+type Method struct {
+}
+
+func New() *Method {
+	return &Method{}
+}
+
+func (m *Method) Caption(context.Context) string {
+	return "Broken"
+}
+
+func (m *Method) Description(context.Context) string {
+	return "The method with the registration problems"
+}
+
+type ReqV1 struct {
+	Ch     chan int   `json:"ch"`
+	Int    int        `json:"int" minimum:"abc"`
+	Cmplx  complex128 `json:"cmplx"`
+	Hidden func()     `json:"-"`
+}
+
+func (m *Method) V1(ctx context.Context, r *ReqV1) (int, error) {
+	return 0, nil
+}
+
+func (m *Method) V2(ctx context.Context, a, b int) (int, error) {
+	return 0, nil
+}
+
+var ErrorsV3 struct {
+	Error1 rpc.ErrorFunc
+}
+
+func (m *Method) ErrorsV3() interface{} {
+	return &ErrorsV3
+}
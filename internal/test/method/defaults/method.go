@@ -0,0 +1,44 @@
+// Package defaults contains the method with the default values of the request fields.
+package defaults
+
+import (
+	"context"
+	"time"
+)
+
+type Method struct {
+}
+
+func New() *Method {
+	return &Method{}
+}
+
+func (m *Method) Caption(context.Context) string {
+	return "Defaults"
+}
+
+func (m *Method) Description(context.Context) string {
+	return "Returns the request with the default values"
+}
+
+type ReqV1 struct {
+	Limit   int           `json:"limit" desc:"Page size" default:"20" maximum:"100"`
+	Offset  *int          `json:"offset" default:"0"`
+	Sort    string        `json:"sort" default:"name" enum:"name,date"`
+	Desc    bool          `json:"desc" default:"true"`
+	Ratio   float64       `json:"ratio" default:"0.5"`
+	Fields  []string      `json:"fields" default:"id, name"`
+	Since   time.Time     `json:"since" default:"2024-01-01T00:00:00Z"`
+	Filter  FilterV1      `json:"filter"`
+	Options *FilterV1     `json:"options"`
+	Timeout time.Duration `json:"timeout" default:"30"`
+}
+
+type FilterV1 struct {
+	Status string `json:"status" default:"active"`
+	Ids    []int  `json:"ids" default:"1,2"`
+}
+
+func (m *Method) V1(ctx context.Context, r *ReqV1) (*ReqV1, error) {
+	return r, nil
+}
@@ -13,6 +13,7 @@ type ReqV1 struct {
 	StructParam    StructV1  `json:"struct_param"`
 	StructPtrParam *StructV1 `json:"struct_ptr_param"`
 	WithErr        bool      `json:"with_err"`
+	WithTypedErr   bool      `json:"with_typed_err"`
 }
 
 type StructV1 struct {
@@ -29,10 +30,16 @@ type DataV1 struct {
 	Str string `json:"str"`
 }
 
+type ErrorDataV1 struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
 var ErrorsV1 struct {
-	Error1 rpc.ErrorFunc `desc:"Error 1"`
-	Error2 rpc.ErrorFunc `desc:"Error 2"`
-	Error3 rpc.ErrorFunc `desc:"Error 3"`
+	Error1 rpc.ErrorFunc               `desc:"Error 1"`
+	Error2 rpc.ErrorFunc               `desc:"Error 2"`
+	Error3 rpc.ErrorFunc               `desc:"Error 3"`
+	Error4 rpc.ErrorFuncT[ErrorDataV1] `desc:"Error 4 with the typed data"`
 }
 
 func (m *Method) ErrorsV1() interface{} {
@@ -44,6 +51,10 @@ func (m *Method) V1(ctx context.Context, r *ReqV1) (*RespV1, error) {
 		return nil, ErrorsV1.Error1("test")
 	}
 
+	if r.WithTypedErr {
+		return nil, ErrorsV1.Error4("test", ErrorDataV1{Field: "with_typed_err", Reason: "test"})
+	}
+
 	return &RespV1{
 		Message: "Hello, world",
 		Data: DataV1{
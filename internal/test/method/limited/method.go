@@ -0,0 +1,45 @@
+// Package limited contains the method with the concurrency limit set by the ConcurrencyLimiter interface.
+package limited
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-qbit/rpc"
+)
+
+type Method struct {
+}
+
+func New() *Method {
+	return &Method{}
+}
+
+func (m *Method) Caption(context.Context) string {
+	return "Limited"
+}
+
+func (m *Method) Description(context.Context) string {
+	return "Sleeps for the requested duration, one call at a time"
+}
+
+func (m *Method) ConcurrencyLimit() rpc.ConcurrencyLimit {
+	return rpc.ConcurrencyLimit{MaxConcurrent: 1}
+}
+
+type ReqV1 struct {
+	Milliseconds int `json:"milliseconds" desc:"The sleep duration"`
+}
+
+func (m *Method) V1(ctx context.Context, r *ReqV1) error {
+	select {
+	case <-time.After(time.Duration(r.Milliseconds) * time.Millisecond):
+	case <-ctx.Done():
+	}
+
+	return nil
+}
+
+func (m *Method) V2(ctx context.Context, r *ReqV1) error {
+	return m.V1(ctx, r)
+}
@@ -0,0 +1,42 @@
+// Package ping contains the method versions without the request or the response.
+package ping
+
+import (
+	"context"
+	"time"
+)
+
+type Method struct {
+}
+
+func New() *Method {
+	return &Method{}
+}
+
+func (m *Method) Caption(context.Context) string {
+	return "Ping"
+}
+
+func (m *Method) Description(context.Context) string {
+	return "Checks the service is alive"
+}
+
+type RespV1 struct {
+	Time time.Time `json:"time"`
+}
+
+func (m *Method) V1(ctx context.Context) (*RespV1, error) {
+	return &RespV1{Time: time.Now()}, nil
+}
+
+type ReqV2 struct {
+	Message string `json:"message"`
+}
+
+func (m *Method) V2(ctx context.Context, r *ReqV2) error {
+	return nil
+}
+
+func (m *Method) V3(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,45 @@
+// Package session contains the method using the HTTP request and response.
+package session
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-qbit/rpc"
+)
+
+type Method struct {
+}
+
+func New() *Method {
+	return &Method{}
+}
+
+func (m *Method) Caption(context.Context) string {
+	return "Session"
+}
+
+func (m *Method) Description(context.Context) string {
+	return "Starts the session"
+}
+
+func (m *Method) ResponseHeaders(context.Context) map[string]string {
+	return map[string]string{
+		"X-Session-Id": "The session ID",
+	}
+}
+
+type RespV1 struct {
+	Client string `json:"client"`
+	Path   string `json:"path"`
+}
+
+func (m *Method) V1(ctx context.Context) (*RespV1, error) {
+	rpc.SetResponseHeader(ctx, "X-Session-Id", "42")
+	rpc.SetCookie(ctx, &http.Cookie{Name: "session", Value: "42"})
+
+	return &RespV1{
+		Client: rpc.RequestFromContext(ctx).Header.Get("X-Client"),
+		Path:   rpc.MethodFromContext(ctx).Path,
+	}, nil
+}
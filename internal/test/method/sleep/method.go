@@ -0,0 +1,38 @@
+package sleep
+
+import (
+	"context"
+	"time"
+)
+
+type Method struct {
+}
+
+func New() *Method {
+	return &Method{}
+}
+
+func (m *Method) Caption(context.Context) string {
+	return "Sleep"
+}
+
+func (m *Method) Description(context.Context) string {
+	return "Sleeps for the requested duration or until the call is canceled"
+}
+
+type ReqV1 struct {
+	Milliseconds int `json:"milliseconds" desc:"The sleep duration"`
+}
+
+type RespV1 struct {
+	Canceled bool `json:"canceled"`
+}
+
+func (m *Method) V1(ctx context.Context, r *ReqV1) (*RespV1, error) {
+	select {
+	case <-time.After(time.Duration(r.Milliseconds) * time.Millisecond):
+		return &RespV1{}, nil
+	case <-ctx.Done():
+		return &RespV1{Canceled: true}, nil
+	}
+}
@@ -0,0 +1,130 @@
+// Package users contains several methods with the explicit paths.
+package users
+
+import (
+	"context"
+
+	"github.com/go-qbit/rpc"
+	"github.com/go-qbit/rpc/openapi"
+)
+
+type User struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type GetMethod struct {
+}
+
+func NewGet() *GetMethod {
+	return &GetMethod{}
+}
+
+func (m *GetMethod) Path() string {
+	return "/users/get"
+}
+
+func (m *GetMethod) Caption(context.Context) string {
+	return "Get user"
+}
+
+func (m *GetMethod) Description(context.Context) string {
+	return "Returns the user by ID"
+}
+
+type GetReqV1 struct {
+	Id int `json:"id"`
+}
+
+func (m *GetMethod) V1(ctx context.Context, r *GetReqV1) (*User, error) {
+	return &User{Id: r.Id, Name: "User"}, nil
+}
+
+type ListMethod struct {
+}
+
+func NewList() *ListMethod {
+	return &ListMethod{}
+}
+
+func (m *ListMethod) Path() string {
+	return "/users/list"
+}
+
+func (m *ListMethod) Caption(context.Context) string {
+	return "List users"
+}
+
+func (m *ListMethod) Description(context.Context) string {
+	return "Returns the users list"
+}
+
+func (m *ListMethod) Tags(context.Context) []string {
+	return []string{"users", "lists"}
+}
+
+func (m *ListMethod) ExternalDocs(context.Context) *openapi.ExternalDocs {
+	return &openapi.ExternalDocs{Url: "https://example.com/docs/users/list"}
+}
+
+func (m *ListMethod) Extensions(context.Context) map[string]interface{} {
+	return map[string]interface{}{"x-rate-limit": 100}
+}
+
+type ListReqV1 struct {
+	Limit int `json:"limit"`
+}
+
+func (m *ListMethod) V1(ctx context.Context, r *ListReqV1) ([]User, error) {
+	return []User{{Id: 1, Name: "User"}}, nil
+}
+
+type DeleteMethod struct {
+}
+
+func NewDelete() *DeleteMethod {
+	return &DeleteMethod{}
+}
+
+func (m *DeleteMethod) Path() string {
+	return "/users/delete"
+}
+
+func (m *DeleteMethod) Caption(context.Context) string {
+	return "Delete users"
+}
+
+func (m *DeleteMethod) Description(context.Context) string {
+	return "Deletes the users and returns the number of the deleted ones"
+}
+
+func (m *DeleteMethod) Visibility(version string) rpc.Visibility {
+	switch version {
+	case "v2":
+		return rpc.Hidden
+	case "v4":
+		return rpc.Internal
+	default:
+		return rpc.Public
+	}
+}
+
+func (m *DeleteMethod) V1(ctx context.Context, ids []int64) (int, error) {
+	return len(ids), nil
+}
+
+type DeleteReqV2 struct {
+	Ids []int64 `json:"ids"`
+}
+
+func (m *DeleteMethod) V2(ctx context.Context, r DeleteReqV2) (int, error) {
+	return len(r.Ids), nil
+}
+
+func (m *DeleteMethod) V3(ctx context.Context, id int64) (int, error) {
+	return int(id), nil
+}
+
+func (m *DeleteMethod) V4(ctx context.Context, id int64) (int, error) {
+	return 1, nil
+}
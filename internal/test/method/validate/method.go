@@ -0,0 +1,147 @@
+// Package validate contains the method with the fields validators.
+package validate
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-qbit/rpc"
+)
+
+type Method struct {
+}
+
+func New() *Method {
+	return &Method{}
+}
+
+func (m *Method) Caption(context.Context) string {
+	return "Validate"
+}
+
+func (m *Method) Description(context.Context) string {
+	return "Checks the fields validators"
+}
+
+type Status string
+
+func (s Status) Enum() []any {
+	return []any{"active", "blocked"}
+}
+
+type ReqV1 struct {
+	Int   int      `json:"int" minimum:"0" exclusiveMaximum:"10" multipleOf:"2"`
+	Uint  uint     `json:"uint" minimum:"1" maximum:"10"`
+	Float float64  `json:"float" exclusiveMinimum:"0" maximum:"2.5" multipleOf:"0.5"`
+	Str   string   `json:"str" minLength:"2" maxLength:"3"`
+	Tags  []string `json:"tags" minItems:"1" maxItems:"3" uniqueItems:"true"`
+	Color string   `json:"color" enum:"red, green"`
+	Level int      `json:"level" enum:"1,2,3"`
+	State Status   `json:"state"`
+}
+
+func (m *Method) V1(ctx context.Context, r *ReqV1) error {
+	return nil
+}
+
+type ReqV2 struct {
+	ID    int64    `json:"id" required:"true"`
+	Items []ItemV2 `json:"items"`
+	Owner *OwnerV2 `json:"owner"`
+}
+
+type ItemV2 struct {
+	Name    string `json:"name" required:"true"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type OwnerV2 struct {
+	Email string `json:"email" required:"true"`
+}
+
+func (m *Method) V2(ctx context.Context, r *ReqV2) error {
+	return nil
+}
+
+type ReqV3 struct {
+	Items  []ItemV3          `json:"items"`
+	ByName map[string]ItemV3 `json:"by_name"`
+	Scores map[string]int    `json:"scores" keyPattern:"^[a-z]+$" minimum:"0"`
+	Codes  []string          `json:"codes" maxItems:"2" pattern:"^[A-Z]{3}$"`
+	Matrix [][]int           `json:"matrix" maxItems:"2" maximum:"9"`
+	States []Status          `json:"states"`
+	Colors []string          `json:"colors" enum:"red,green"`
+}
+
+type ItemV3 struct {
+	F1 uint `json:"f1" minimum:"1"`
+}
+
+func (m *Method) V3(ctx context.Context, r *ReqV3) error {
+	return nil
+}
+
+type ReqV4 struct {
+	Email    string   `json:"email" format:"email"`
+	UUID     string   `json:"uuid" format:"uuid"`
+	DateTime string   `json:"date_time" format:"date-time"`
+	Date     string   `json:"date" format:"date"`
+	URI      string   `json:"uri" format:"uri"`
+	Hostname string   `json:"hostname" format:"hostname"`
+	IPv4     string   `json:"ipv4" format:"ipv4"`
+	IPv6     string   `json:"ipv6" format:"ipv6"`
+	Emails   []string `json:"emails" format:"email"`
+}
+
+func (m *Method) V4(ctx context.Context, r *ReqV4) error {
+	return nil
+}
+
+type ReqV5 struct {
+	StartDate time.Time        `json:"start_date"`
+	EndDate   time.Time        `json:"end_date"`
+	Contacts  []ContactV5      `json:"contacts"`
+	ByName    map[string]TagV5 `json:"by_name"`
+}
+
+func (r *ReqV5) Validate(ctx context.Context) error {
+	if r.EndDate.Before(r.StartDate) {
+		return rpc.FieldError{Path: "end_date", Message: "must be after start_date"}
+	}
+
+	if r.StartDate.IsZero() && len(r.Contacts) > 0 {
+		return errors.New("start_date is required for the contacts")
+	}
+
+	return nil
+}
+
+type ContactV5 struct {
+	Email string `json:"email" format:"email"`
+	Phone string `json:"phone"`
+}
+
+func (c ContactV5) Validate() []rpc.FieldError {
+	if c.Email == "" && c.Phone == "" {
+		return []rpc.FieldError{{Message: "either email or phone is required"}}
+	}
+
+	return nil
+}
+
+type TagV5 struct {
+	Name string `json:"name"`
+}
+
+func (t *TagV5) Validate() []rpc.FieldError {
+	if t.Name == "" {
+		return []rpc.FieldError{{Path: "name", Message: "must not be empty"}}
+	}
+
+	return nil
+}
+
+func (m *Method) V5(ctx context.Context, r *ReqV5) error {
+	return nil
+}
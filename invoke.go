@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound    = errors.New("method not found")
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// Invoke calls the method by the path in-process. The request is the JSON data as for ServeHTTP.
+// The call is processed exactly as the HTTP one, the business errors are returned as *Error.
+// The response is nil for the methods without the response.
+// RequestFromContext returns nil inside the method and the response headers are discarded.
+func (r *Rpc) Invoke(ctx context.Context, path string, req []byte) ([]byte, error) {
+	owner, method := r.lookup(path)
+	if method == nil {
+		return nil, fmt.Errorf("%s: %w", path, ErrNotFound)
+	}
+
+	resp, _, err := r.call(ctx, owner, path, method, owner.effectiveOptions(), nil, bytes.NewReader(req), "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	if method.Response == nil {
+		if owner.effectiveOptions().emptyObject {
+			return []byte("{}"), nil
+		}
+		return nil, nil
+	}
+
+	return json.Marshal(resp)
+}
+
+// Call is the typed version of Invoke. The request and response are marshaled to JSON,
+// so the method receives the same data as via HTTP.
+func Call[Req, Resp any](ctx context.Context, r *Rpc, path string, req *Req) (*Resp, error) {
+	reqData, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respData, err := r.Invoke(ctx, path, reqData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(Resp)
+	if len(respData) > 0 {
+		if err := json.Unmarshal(respData, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
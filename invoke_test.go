@@ -0,0 +1,97 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mHello "github.com/go-qbit/rpc/internal/test/method/hello"
+	mPing "github.com/go-qbit/rpc/internal/test/method/ping"
+	mUsers "github.com/go-qbit/rpc/internal/test/method/users"
+)
+
+func TestRpc_Invoke(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethods(mHello.New(), mPing.New(), mUsers.NewDelete()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	resp, err := r.Invoke(ctx, "/hello/v1", []byte(`{"int_param": 150, "str_param": "str value", "struct_param": {"f1": 10}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) == 0 {
+		t.Fatal("Empty response")
+	}
+
+	_, err = r.Invoke(ctx, "/hello/v1", []byte(`{"int_param": 1}`))
+	var rpcErr *rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != "VALIDATION_ERROR" {
+		t.Fatalf("Invalid error %v, expected VALIDATION_ERROR", err)
+	}
+
+	if _, err := r.Invoke(ctx, "/unknown/v1", nil); !errors.Is(err, rpc.ErrNotFound) {
+		t.Fatalf("Invalid error %v, expected ErrNotFound", err)
+	}
+
+	resp, err = r.Invoke(ctx, "/ping/v3", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != nil {
+		t.Fatalf("Invalid response '%s', expected nil", resp)
+	}
+
+	// Internal methods are available in-process
+	if _, err := r.Invoke(ctx, "/users/delete/v4", []byte(`1`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mHello.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := rpc.Call[mHello.ReqV1, mHello.RespV1](context.Background(), r, "/hello/v1", &mHello.ReqV1{
+		IntParam:    150,
+		StrParam:    "str value",
+		StructParam: mHello.StructV1{F1: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "Hello, world" {
+		t.Fatalf("Invalid message '%s'", resp.Message)
+	}
+
+	_, err = rpc.Call[mHello.ReqV1, mHello.RespV1](context.Background(), r, "/hello/v1", &mHello.ReqV1{
+		IntParam:    150,
+		StrParam:    "str value",
+		StructParam: mHello.StructV1{F1: 10},
+		WithErr:     true,
+	})
+	var rpcErr *rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != "Error1" {
+		t.Fatalf("Invalid error %v, expected Error1", err)
+	}
+}
+
+func TestRpc_Invoke_Shutdown(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mPing.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Invoke(context.Background(), "/ping/v3", nil); !errors.Is(err, rpc.ErrUnavailable) {
+		t.Fatalf("Invalid error %v, expected ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const ErrorCodeOverloaded = "OVERLOADED"
+
+// ConcurrencyLimit restricts the number of the calls processed at the same time.
+// Calls exceeding MaxConcurrent wait in a queue of MaxQueue size for at most QueueTimeout
+// (zero means until the call is canceled), then they are rejected with the OVERLOADED error.
+type ConcurrencyLimit struct {
+	MaxConcurrent int
+	MaxQueue      int
+	QueueTimeout  time.Duration
+}
+
+// ConcurrencyLimiter is an optional Method interface to set the limit shared by all the method versions.
+type ConcurrencyLimiter interface {
+	ConcurrencyLimit() ConcurrencyLimit
+}
+
+type limiter struct {
+	limit  ConcurrencyLimit
+	slots  chan struct{}
+	queued int32
+}
+
+func newLimiter(limit ConcurrencyLimit) *limiter {
+	if limit.MaxConcurrent <= 0 {
+		return nil
+	}
+
+	return &limiter{
+		limit: limit,
+		slots: make(chan struct{}, limit.MaxConcurrent),
+	}
+}
+
+func (l *limiter) acquire(ctx context.Context) bool {
+	if l == nil {
+		return true
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if atomic.AddInt32(&l.queued, 1) > int32(l.limit.MaxQueue) {
+		atomic.AddInt32(&l.queued, -1)
+		return false
+	}
+	defer atomic.AddInt32(&l.queued, -1)
+
+	var timeout <-chan time.Time
+	if l.limit.QueueTimeout > 0 {
+		timer := time.NewTimer(l.limit.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timeout:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *limiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
+
+// acquire takes the method slot before the global one, so the calls queued for a slow method
+// do not hold the global slots needed by the other methods.
+func (r *Rpc) acquire(ctx context.Context, method *MethodDesc) (func(), bool) {
+	if !method.limiter.acquire(ctx) {
+		return nil, false
+	}
+
+	global := r.globalLimiter()
+	if !global.acquire(ctx) {
+		method.limiter.release()
+		return nil, false
+	}
+
+	return func() {
+		global.release()
+		method.limiter.release()
+	}, true
+}
@@ -0,0 +1,181 @@
+package rpc_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-qbit/rpc"
+	mLimited "github.com/go-qbit/rpc/internal/test/method/limited"
+	mPing "github.com/go-qbit/rpc/internal/test/method/ping"
+	mSleep "github.com/go-qbit/rpc/internal/test/method/sleep"
+)
+
+// postLimited returns the status code and the error of the call.
+func postLimited(t *testing.T, srv *httptest.Server, path, body string) (int, rpc.Error) {
+	resp, err := srv.Client().Post(srv.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Error(err)
+		return 0, rpc.Error{}
+	}
+	defer resp.Body.Close()
+
+	var rpcErr rpc.Error
+	if resp.StatusCode >= http.StatusBadRequest {
+		_ = json.NewDecoder(resp.Body).Decode(&rpcErr)
+	}
+
+	return resp.StatusCode, rpcErr
+}
+
+func TestRpc_ConcurrencyLimit(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method",
+		rpc.WithMethodConcurrencyLimit("/sleep/v1", rpc.ConcurrencyLimit{
+			MaxConcurrent: 1,
+			MaxQueue:      1,
+			QueueTimeout:  50 * time.Millisecond,
+		}),
+	)
+	if err := r.RegisterMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	post := func(body string) (int, rpc.Error) {
+		return postLimited(t, srv, "/sleep/v1", body)
+	}
+
+	done := make(chan int)
+	go func() {
+		status, _ := post(`{"milliseconds": 300}`)
+		done <- status
+	}()
+
+	waitActiveCalls(t, r, 1)
+	time.Sleep(20 * time.Millisecond) // let the call take the slot
+
+	// The queued call times out
+	status, rpcErr := post(`{}`)
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("Invalid status code = %d, expected 503", status)
+	}
+	if rpcErr.Code != rpc.ErrorCodeOverloaded {
+		t.Fatalf("Invalid error code field = '%s', expected '%s'", rpcErr.Code, rpc.ErrorCodeOverloaded)
+	}
+
+	if status := <-done; status != http.StatusOK {
+		t.Fatalf("Invalid status code = %d, expected 200", status)
+	}
+
+	if status, _ := post(`{}`); status != http.StatusOK {
+		t.Fatalf("Invalid status code = %d, expected 200", status)
+	}
+}
+
+func TestRpc_GlobalConcurrencyLimit(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method",
+		rpc.WithConcurrencyLimit(rpc.ConcurrencyLimit{MaxConcurrent: 1}),
+	)
+	if err := r.RegisterMethods(mSleep.New(), mPing.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	done := make(chan int)
+	go func() {
+		status, _ := postLimited(t, srv, "/sleep/v1", `{"milliseconds": 200}`)
+		done <- status
+	}()
+
+	waitActiveCalls(t, r, 1)
+	time.Sleep(20 * time.Millisecond) // let the call take the slot
+
+	// The limit is shared by all the methods
+	status, rpcErr := postLimited(t, srv, "/ping/v3", `{}`)
+	if status != http.StatusServiceUnavailable || rpcErr.Code != rpc.ErrorCodeOverloaded {
+		t.Fatalf("Invalid status code = %d, error code '%s', expected 503 %s", status, rpcErr.Code, rpc.ErrorCodeOverloaded)
+	}
+
+	if status := <-done; status != http.StatusOK {
+		t.Fatalf("Invalid status code = %d, expected 200", status)
+	}
+
+	if status, _ := postLimited(t, srv, "/ping/v3", `{}`); status != http.StatusNoContent {
+		t.Fatalf("Invalid status code = %d, expected 204", status)
+	}
+}
+
+func TestRpc_ConcurrencyLimit_QueuedNotHoldingGlobal(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method",
+		rpc.WithConcurrencyLimit(rpc.ConcurrencyLimit{MaxConcurrent: 3}),
+		rpc.WithMethodConcurrencyLimit("/sleep/v1", rpc.ConcurrencyLimit{MaxConcurrent: 1, MaxQueue: 5}),
+	)
+	if err := r.RegisterMethods(mSleep.New(), mPing.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	done := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			status, _ := postLimited(t, srv, "/sleep/v1", `{"milliseconds": 100}`)
+			done <- status
+		}()
+	}
+
+	waitActiveCalls(t, r, 3)
+	time.Sleep(20 * time.Millisecond) // let the calls take the slot and the queue
+
+	// Only one sleep call is running, the queued ones do not take the global slots
+	if status, rpcErr := postLimited(t, srv, "/ping/v3", `{}`); status != http.StatusNoContent {
+		t.Fatalf("Invalid status code = %d, error code '%s', expected 204", status, rpcErr.Code)
+	}
+
+	for i := 0; i < 3; i++ {
+		if status := <-done; status != http.StatusOK {
+			t.Fatalf("Invalid status code = %d, expected 200", status)
+		}
+	}
+}
+
+func TestRpc_ConcurrencyLimiter(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mLimited.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	done := make(chan int)
+	go func() {
+		status, _ := postLimited(t, srv, "/limited/v1", `{"milliseconds": 200}`)
+		done <- status
+	}()
+
+	waitActiveCalls(t, r, 1)
+	time.Sleep(20 * time.Millisecond) // let the call take the slot
+
+	// The limit is shared by the method versions
+	status, rpcErr := postLimited(t, srv, "/limited/v2", `{}`)
+	if status != http.StatusServiceUnavailable || rpcErr.Code != rpc.ErrorCodeOverloaded {
+		t.Fatalf("Invalid status code = %d, error code '%s', expected 503 %s", status, rpcErr.Code, rpc.ErrorCodeOverloaded)
+	}
+
+	if status := <-done; status != http.StatusNoContent {
+		t.Fatalf("Invalid status code = %d, expected 204", status)
+	}
+
+	if status, _ := postLimited(t, srv, "/limited/v2", `{}`); status != http.StatusNoContent {
+		t.Fatalf("Invalid status code = %d, expected 204", status)
+	}
+}
@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-qbit/rpc/openapi"
+)
+
+// TagsProvider is an optional Method interface to set the OpenAPI tags.
+// By default the tag is the first segment of the method path.
+type TagsProvider interface {
+	Tags(ctx context.Context) []string
+}
+
+// ExternalDocsProvider is an optional Method interface to link the external documentation.
+type ExternalDocsProvider interface {
+	ExternalDocs(ctx context.Context) *openapi.ExternalDocs
+}
+
+// ExtensionsProvider is an optional Method interface to add the vendor x- fields to the OpenAPI operation.
+type ExtensionsProvider interface {
+	Extensions(ctx context.Context) map[string]interface{}
+}
+
+type Visibility int
+
+const (
+	// Public methods are served and documented
+	Public Visibility = iota
+	// Hidden methods are served, but excluded from the public OpenAPI and the TypeScript client
+	Hidden
+	// Internal methods are served only by the Internal handler and excluded from the public OpenAPI and the TypeScript client
+	Internal
+)
+
+// VisibilityProvider is an optional Method interface to set the visibility of a method version, e.g. "v1".
+type VisibilityProvider interface {
+	Visibility(version string) Visibility
+}
+
+// WithTag adds the tag description to the OpenAPI document.
+func WithTag(name, description string, externalDocs *openapi.ExternalDocs) OptsFunc {
+	return func(opts *opts) {
+		opts.tags = append(opts.tags, openapi.Tag{
+			Name:         name,
+			Description:  description,
+			ExternalDocs: externalDocs,
+		})
+	}
+}
+
+func methodTags(ctx context.Context, path string, md *MethodDesc) []string {
+	if t, ok := md.Method.(TagsProvider); ok {
+		if tags := t.Tags(ctx); len(tags) > 0 {
+			return tags
+		}
+	}
+
+	return []string{strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]}
+}
+
+// GetPublicPaths returns the sorted paths of the public methods.
+func (r *Rpc) GetPublicPaths() []string {
+	var res []string
+	for _, path := range r.GetPaths() {
+		if md := r.GetMethod(path); md != nil && md.Visibility == Public {
+			res = append(res, path)
+		}
+	}
+
+	return res
+}
+
+type internalHandler struct {
+	r *Rpc
+}
+
+func (h internalHandler) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	h.r.serveHTTP(w, request, true)
+}
+
+// Internal returns the handler serving all the methods including the Internal ones.
+func (r *Rpc) Internal() http.Handler {
+	return internalHandler{r}
+}
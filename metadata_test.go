@@ -0,0 +1,91 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mUsers "github.com/go-qbit/rpc/internal/test/method/users"
+)
+
+func TestRpc_GetSwagger_Metadata(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method",
+		rpc.WithTag("users", "The users management", nil),
+	)
+	if err := r.RegisterMethods(mUsers.NewGet(), mUsers.NewList(), mUsers.NewDelete()); err != nil {
+		t.Fatal(err)
+	}
+
+	swagger := r.GetSwagger(context.Background())
+
+	if len(swagger.Tags) != 1 || swagger.Tags[0].Description != "The users management" {
+		t.Fatalf("Invalid tags %+v", swagger.Tags)
+	}
+
+	if tags := swagger.Paths["/users/get/v1"].Post.Tags; len(tags) != 1 || tags[0] != "users" {
+		t.Fatalf("Invalid default tags %v", tags)
+	}
+
+	list := swagger.Paths["/users/list/v1"].Post
+	if len(list.Tags) != 2 || list.ExternalDocs == nil {
+		t.Fatalf("Invalid list metadata %+v", list)
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"x-rate-limit":100`) {
+		t.Fatalf("No extension in '%s'", data)
+	}
+
+	for _, path := range []string{"/users/delete/v2", "/users/delete/v4"} {
+		if _, exists := swagger.Paths[path]; exists {
+			t.Fatalf("Not public method %s in the public OpenAPI", path)
+		}
+		if _, exists := r.GetInternalSwagger(context.Background()).Paths[path]; !exists {
+			t.Fatalf("No method %s in the internal OpenAPI", path)
+		}
+	}
+	if _, exists := swagger.Paths["/users/delete/v1"]; !exists {
+		t.Fatalf("No public method in the OpenAPI")
+	}
+}
+
+func TestRpc_ServeHTTP_Visibility(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mUsers.NewDelete()); err != nil {
+		t.Fatal(err)
+	}
+
+	public := httptest.NewServer(r)
+	defer public.Close()
+
+	internal := httptest.NewServer(r.Internal())
+	defer internal.Close()
+
+	for _, tc := range []struct {
+		srv    *httptest.Server
+		path   string
+		body   string
+		status int
+	}{
+		{public, "/users/delete/v2", `{"ids": [1]}`, http.StatusOK},
+		{public, "/users/delete/v4", `1`, http.StatusNotFound},
+		{internal, "/users/delete/v4", `1`, http.StatusOK},
+	} {
+		resp, err := tc.srv.Client().Post(tc.srv.URL+tc.path, "application/json", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.status {
+			t.Fatalf("Invalid status code for %s = %d, expected %d", tc.path, resp.StatusCode, tc.status)
+		}
+	}
+}
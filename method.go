@@ -3,12 +3,14 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 type Method interface {
@@ -16,14 +18,26 @@ type Method interface {
 	Description(ctx context.Context) string
 }
 
+// PathProvider is an optional Method interface to set the method base path explicitly,
+// e.g. "/users/get". By default the path is the method package path without the trim prefix.
+type PathProvider interface {
+	Path() string
+}
+
 type MethodDesc struct {
 	Path       string
 	Method     Method
-	Request    reflect.Type
-	Response   reflect.Type
+	Request    reflect.Type // nil if the method has no request
+	Response   reflect.Type // nil if the method has no response
 	Func       reflect.Value
 	Errors     map[string]string
-	Validators map[string][]validateFunc
+	ErrorTypes map[string]reflect.Type // The data types of the errors declared by ErrorFuncT
+	Visibility Visibility
+
+	limiter   *limiter
+	defaults  *defaultValues
+	required  *requiredFields
+	validator *typeValidator
 }
 
 var (
@@ -32,6 +46,15 @@ var (
 )
 
 func getMethodPath(m Method, trimPrefix string) (string, error) {
+	if p, ok := m.(PathProvider); ok {
+		path := strings.Trim(p.Path(), "/")
+		if path == "" {
+			return "", fmt.Errorf("empty method path")
+		}
+
+		return "/" + path, nil
+	}
+
 	trimPrefix = strings.TrimSuffix(trimPrefix, "/")
 
 	mType := reflect.TypeOf(m)
@@ -49,94 +72,141 @@ func getMethodPath(m Method, trimPrefix string) (string, error) {
 }
 
 func ParseMethodDesc(m Method, trimPrefix string) ([]*MethodDesc, error) {
-	return descsFromMethod(m, trimPrefix)
+	mds, problems := descsFromMethod(m, trimPrefix)
+	if len(problems) > 0 {
+		return nil, &RegistrationError{Method: fmt.Sprintf("%T", m), Problems: problems}
+	}
+
+	return mds, nil
 }
 
-func descsFromMethod(m Method, trimPrefix string) ([]*MethodDesc, error) {
+func descsFromMethod(m Method, trimPrefix string) ([]*MethodDesc, []error) {
 	path, err := getMethodPath(m, trimPrefix)
 	if err != nil {
-		return nil, err
+		return nil, []error{err}
 	}
 
-	var res []*MethodDesc
+	var (
+		res      []*MethodDesc
+		problems []error
+	)
 
 	mType := reflect.TypeOf(m)
 	for i := 0; i < mType.NumMethod(); i++ {
 		goMethod := mType.Method(i)
 
 		if reMethodVersion.MatchString(goMethod.Name) {
-			if goMethod.Type.NumIn() != 3 || goMethod.Type.In(1).String() != "context.Context" {
-				return nil, fmt.Errorf("invalid method %s signature, must be (context.Context, <request type>)", goMethod.Name)
+			mt := goMethod.Type
+			if mt.NumIn() < 2 || mt.NumIn() > 3 || mt.In(1).String() != "context.Context" {
+				problems = append(problems, fmt.Errorf("invalid method %s signature, must be (context.Context[, <request type>])", goMethod.Name))
+				continue
 			}
 
-			if goMethod.Type.NumOut() != 2 || goMethod.Type.Out(1).String() != "error" {
-				return nil, fmt.Errorf("invalid method %s return signature, must be (<response type>), error", goMethod.Name)
+			if mt.NumOut() < 1 || mt.NumOut() > 2 || mt.Out(mt.NumOut()-1).String() != "error" {
+				problems = append(problems, fmt.Errorf("invalid method %s return signature, must be ([<response type>, ]error)", goMethod.Name))
+				continue
 			}
 
-			validators := map[string][]validateFunc{}
-			if err := getValidators(goMethod.Type.In(2), validators, ""); err != nil {
-				return nil, err
+			var request, response reflect.Type
+			if mt.NumIn() == 3 {
+				request = mt.In(2)
+			}
+			if mt.NumOut() == 2 {
+				response = mt.Out(0)
+			}
+
+			md, mdProblems := newMethodDesc(goMethod.Name, path+"/"+strings.ToLower(goMethod.Name), m, goMethod.Func, request, response)
+			if len(mdProblems) > 0 {
+				problems = append(problems, mdProblems...)
+				continue
 			}
-			res = append(res, &MethodDesc{
-				Path:       path + "/" + strings.ToLower(goMethod.Name),
-				Method:     m,
-				Func:       goMethod.Func,
-				Request:    goMethod.Type.In(2),
-				Response:   goMethod.Type.Out(0),
-				Errors:     map[string]string{},
-				Validators: validators,
-			})
+
+			if v, ok := m.(VisibilityProvider); ok {
+				md.Visibility = v.Visibility(strings.ToLower(goMethod.Name))
+			}
+
+			res = append(res, md)
 		}
 	}
 
-	return res, nil
+	return res, problems
 }
 
-func getValidators(t reflect.Type, validatorsMap map[string][]validateFunc, curPath string) error {
-	switch t.Kind() {
-	case reflect.Ptr:
-		return getValidators(t.Elem(), validatorsMap, curPath)
-
-	case reflect.Struct:
-		for i := 0; i < t.NumField(); i++ {
-			field := t.Field(i)
-			fieldType := field.Type
-
-			if fieldType.Kind() == reflect.Ptr {
-				fieldType = fieldType.Elem()
-			}
+// newMethodDesc checks the request and response types and builds the method description.
+// The name is used in the problems description.
+// The request or response type is nil if the method has no request or response.
+func newMethodDesc(name, path string, m Method, fn reflect.Value, request, response reflect.Type) (*MethodDesc, []error) {
+	var problems []error
+
+	var (
+		validator *typeValidator
+		defaults  *defaultValues
+		required  *requiredFields
+	)
+	if request != nil {
+		problems = append(problems, checkType(request, name+" request", map[reflect.Type]bool{})...)
+
+		var validatorProblems []error
+		validator, validatorProblems = newTypeValidator(reflect.StructField{Type: request}, nil, map[reflect.Type]*structValidator{}, "")
+		for _, err := range validatorProblems {
+			problems = append(problems, fmt.Errorf("%s request%w", name, err))
+		}
 
-			switch fieldType.Kind() {
-			case reflect.Struct:
-				if err := getValidators(field.Type, validatorsMap, curPath+"/"+field.Name); err != nil {
-					return err
-				}
+		var requiredProblems []error
+		required, requiredProblems = getRequired(request, "", map[reflect.Type]bool{})
+		for _, err := range requiredProblems {
+			problems = append(problems, fmt.Errorf("%s request%w", name, err))
+		}
 
-			default:
-				for _, validator := range validators[field.Type.Kind()] {
-					vFunc, err := validator.GetValidateFunc(field)
-					if err != nil {
-						return err
-					}
+		// The invalid defaults are reported by checkDefaults, including the ones getDefaults skips
+		defaults, _ = getDefaults(request, "", map[reflect.Type]bool{})
+		for _, err := range checkDefaults(request, "", map[reflect.Type]bool{}) {
+			problems = append(problems, fmt.Errorf("%s request%w", name, err))
+		}
+	}
 
-					if vFunc != nil {
-						path := curPath + "/" + field.Name
-						validatorsMap[path] = append(validatorsMap[path], vFunc)
-					}
-				}
-			}
+	if response != nil {
+		problems = append(problems, checkType(response, name+" response", map[reflect.Type]bool{})...)
+		// The response validators are not used, but the tags are checked for the documentation
+		_, validatorProblems := newTypeValidator(reflect.StructField{Type: response}, nil, map[reflect.Type]*structValidator{}, "")
+		for _, err := range validatorProblems {
+			problems = append(problems, fmt.Errorf("%s response%w", name, err))
 		}
+		// The defaults are shown in the documentation only
+		for _, err := range checkDefaults(response, "", map[reflect.Type]bool{}) {
+			problems = append(problems, fmt.Errorf("%s response%w", name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
 	}
 
-	return nil
+	return &MethodDesc{
+		Path:       path,
+		Method:     m,
+		Func:       fn,
+		Request:    request,
+		Response:   response,
+		Errors:     map[string]string{},
+		ErrorTypes: map[string]reflect.Type{},
+		defaults:   defaults,
+		required:   required,
+		validator:  validator,
+	}, nil
 }
 
-func bindErrors(m Method, trimPrefix string, methods map[string]*MethodDesc) error {
+func bindErrors(m Method, trimPrefix string, methods map[string]*MethodDesc) ([]errorBinding, []error) {
 	path, err := getMethodPath(m, trimPrefix)
 	if err != nil {
-		return err
+		return nil, []error{err}
 	}
 
+	var (
+		bindings []errorBinding
+		problems []error
+	)
+
 	mType := reflect.TypeOf(m)
 	for i := 0; i < mType.NumMethod(); i++ {
 		goMethod := mType.Method(i)
@@ -147,26 +217,84 @@ func bindErrors(m Method, trimPrefix string, methods map[string]*MethodDesc) err
 		}
 
 		methodPath := path + "/v" + submatch[1]
+		md := methods[methodPath]
+		if md == nil || reflect.TypeOf(md.Method) != mType {
+			problems = append(problems, fmt.Errorf("%s has no corresponding method V%s", goMethod.Name, submatch[1]))
+			continue
+		}
+
+		if goMethod.Type.NumIn() != 1 || goMethod.Type.NumOut() != 1 {
+			problems = append(problems, fmt.Errorf("invalid method %s signature, must be () interface{}", goMethod.Name))
+			continue
+		}
 
 		errorsVar := goMethod.Func.Call([]reflect.Value{reflect.ValueOf(m)})[0]
-		errorsVar = errorsVar.Elem()
-		if errorsVar.Kind() != reflect.Ptr || errorsVar.Elem().Kind() != reflect.Struct {
-			return fmt.Errorf("errors variable must be a pointer to a structure")
+		if errorsVar.Kind() == reflect.Interface {
+			errorsVar = errorsVar.Elem()
 		}
-		errorsVar = errorsVar.Elem()
+		problems = append(problems, bindErrorsStruct(goMethod.Name, errorsVar, md.Errors, md.ErrorTypes, &bindings)...)
+	}
 
-		for i := 0; i < errorsVar.NumField(); i++ {
-			ft := errorsVar.Type().Field(i)
-			if ft.Type.Name() != "ErrorFunc" || ft.Type.PkgPath() != "github.com/go-qbit/rpc" {
-				return fmt.Errorf("error type for %s must be github.com/go-qbit/rpc.ErrorFunc", ft.Name)
-			}
+	return bindings, problems
+}
 
-			methods[methodPath].Errors[ft.Name] = ft.Tag.Get("desc")
+// errorBinding is the ErrorFunc value of the errors structure field. The bindings are set by setErrorFuncs
+// only after the successful registration checks.
+type errorBinding struct {
+	field reflect.Value
+	value reflect.Value
+}
 
-			f := errorsVar.Field(i)
-			errFunc := ErrorFunc(func(message string, data ...interface{}) *Error {
+// errorFuncsMtx serializes the bindings of the errors structures shared by the registrations.
+var errorFuncsMtx sync.Mutex
+
+// setErrorFuncs sets the unset fields only. The bound fields are never written again,
+// so the calls reading them concurrently with the replacement of the method don't race.
+func setErrorFuncs(bindings []errorBinding) {
+	errorFuncsMtx.Lock()
+	defer errorFuncsMtx.Unlock()
+
+	for _, b := range bindings {
+		if b.field.IsNil() {
+			b.field.Set(b.value)
+		}
+	}
+}
+
+// bindErrorsStruct collects the bindings of the ErrorFunc fields of the pointer to the errors structure
+// and adds the errors descriptions and data types to the maps.
+// The embedded structures are bound recursively, so the common errors can be shared.
+func bindErrorsStruct(name string, errorsVar reflect.Value, errors map[string]string, errorTypes map[string]reflect.Type, bindings *[]errorBinding) []error {
+	if errorsVar.Kind() != reflect.Ptr || errorsVar.Elem().Kind() != reflect.Struct {
+		return []error{fmt.Errorf("%s: errors variable must be a pointer to a structure", name)}
+	}
+	errorsVar = errorsVar.Elem()
+
+	var problems []error
+
+	for i := 0; i < errorsVar.NumField(); i++ {
+		ft := errorsVar.Type().Field(i)
+		f := errorsVar.Field(i)
+
+		switch {
+		case ft.Anonymous && ft.Type.Kind() == reflect.Struct:
+			problems = append(problems, bindErrorsStruct(name, f.Addr(), errors, errorTypes, bindings)...)
+			continue
+
+		case ft.Anonymous && ft.Type.Kind() == reflect.Ptr && ft.Type.Elem().Kind() == reflect.Struct:
+			embedded := f
+			if f.IsNil() { // The new structure is set with the rest bindings
+				embedded = reflect.New(ft.Type.Elem())
+				*bindings = append(*bindings, errorBinding{field: f, value: embedded})
+			}
+			problems = append(problems, bindErrorsStruct(name, embedded, errors, errorTypes, bindings)...)
+			continue
+
+		case ft.Type.PkgPath() == "github.com/go-qbit/rpc" && ft.Type.Name() == "ErrorFunc":
+			code := ft.Name
+			*bindings = append(*bindings, errorBinding{field: f, value: reflect.ValueOf(ErrorFunc(func(message string, data ...interface{}) *Error {
 				res := &Error{
-					Code:    ft.Name,
+					Code:    code,
 					Message: message,
 				}
 
@@ -175,19 +303,76 @@ func bindErrors(m Method, trimPrefix string, methods map[string]*MethodDesc) err
 				}
 
 				return res
-			})
+			}))})
+
+		case ft.Type.PkgPath() == "github.com/go-qbit/rpc" && strings.HasPrefix(ft.Type.Name(), "ErrorFuncT["):
+			dataType := ft.Type.In(1)
+			if typeProblems := checkType(dataType, name+" "+ft.Name+" data", map[reflect.Type]bool{}); len(typeProblems) > 0 {
+				problems = append(problems, typeProblems...)
+				continue
+			}
 
-			f.Set(reflect.ValueOf(errFunc))
+			code := ft.Name
+			*bindings = append(*bindings, errorBinding{field: f, value: reflect.MakeFunc(ft.Type, func(args []reflect.Value) []reflect.Value {
+				return []reflect.Value{reflect.ValueOf(&Error{
+					Code:    code,
+					Message: args[0].String(),
+					Data:    args[1].Interface(),
+				})}
+			})})
+			errorTypes[ft.Name] = dataType
+
+		default:
+			problems = append(problems, fmt.Errorf("%s: error type for %s must be github.com/go-qbit/rpc.ErrorFunc or ErrorFuncT", name, ft.Name))
+			continue
 		}
+
+		errors[ft.Name] = ft.Tag.Get("desc")
 	}
 
-	return nil
+	return problems
 }
 
+// Call decodes the request, calls the method and returns the response.
+// The response is nil for the methods without the response.
 func (m *MethodDesc) Call(ctx context.Context, r io.Reader, boundary string, maxMemory int64) (interface{}, error) {
-	req := reflect.New(m.Request.Elem())
+	args := []reflect.Value{reflect.ValueOf(m.Method), reflect.ValueOf(ctx)}
+
+	if m.Request != nil {
+		req, err := m.decodeRequest(ctx, r, boundary, maxMemory)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, req)
+	}
+
+	res := m.Func.Call(args)
+
+	if errVal := res[len(res)-1]; !errVal.IsNil() {
+		return nil, errVal.Interface().(error)
+	}
+
+	if m.Response == nil {
+		return nil, nil
+	}
+
+	return res[0].Interface(), nil
+}
+
+func (m *MethodDesc) decodeRequest(ctx context.Context, r io.Reader, boundary string, maxMemory int64) (reflect.Value, error) {
+	reqType := m.Request
+	if reqType.Kind() == reflect.Ptr {
+		reqType = reqType.Elem()
+	}
+	req := reflect.New(reqType)
+	m.defaults.apply(req.Elem())
+
+	var validationErrs []ValidationError
 
 	if boundary != "" {
+		// The keys of all the JSON parts and the file parts names for the required fields check
+		present := map[string]json.RawMessage{}
+
 		reader := multipart.NewReader(r, boundary)
 		for {
 			p, err := reader.NextPart()
@@ -197,7 +382,7 @@ func (m *MethodDesc) Call(ctx context.Context, r io.Reader, boundary string, max
 			}
 
 			if err != nil {
-				return nil, err
+				return reflect.Value{}, err
 			}
 
 			var file File
@@ -206,46 +391,94 @@ func (m *MethodDesc) Call(ctx context.Context, r io.Reader, boundary string, max
 				buf := &buffer{}
 				n, err := io.CopyN(buf, p, maxMemory+1)
 				if err != nil && err != io.EOF {
-					return nil, err
+					return reflect.Value{}, err
 				}
 				file = buf
 
 				if n > maxMemory {
 					file, err = newTmpFile(buf, p)
 					if err != nil {
-						return nil, err
+						return reflect.Value{}, err
 					}
 				}
 				req.Elem().FieldByName(name).Set(reflect.ValueOf(file))
+				present[p.FormName()] = json.RawMessage("true")
 				continue
 			}
-			if err := json.NewDecoder(p).Decode(req.Interface()); err != nil && err != io.EOF {
-				return nil, &Error{Code: "INVALID_JSON", Message: err.Error()}
+			var data json.RawMessage
+			if err := json.NewDecoder(p).Decode(&data); err != nil {
+				if err == io.EOF {
+					continue
+				}
+				return reflect.Value{}, decodeError(err)
+			}
+			if err := json.Unmarshal(data, req.Interface()); err != nil {
+				return reflect.Value{}, decodeError(err)
+			}
+
+			if m.required != nil {
+				var fields map[string]json.RawMessage
+				_ = json.Unmarshal(data, &fields)
+				for key, value := range fields {
+					present[key] = value
+				}
 			}
 		}
 
+		if m.required != nil {
+			data, err := json.Marshal(present)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			m.required.check(data, valuePath{}, &validationErrs)
+		}
+
+	} else if m.required != nil {
+		var data json.RawMessage
+		if err := json.NewDecoder(r).Decode(&data); err != nil {
+			return reflect.Value{}, decodeError(err)
+		}
+		if err := json.Unmarshal(data, req.Interface()); err != nil {
+			return reflect.Value{}, decodeError(err)
+		}
+		m.required.check(data, valuePath{}, &validationErrs)
+
 	} else {
 		if err := json.NewDecoder(r).Decode(req.Interface()); err != nil {
-			return nil, &Error{Code: "INVALID_JSON", Message: err.Error()}
+			return reflect.Value{}, decodeError(err)
 		}
 	}
 
-	if len(m.Validators) > 0 {
-		if err := m.validateData(req, ""); err != nil {
-			return nil, &Error{Code: "INVALID_JSON", Message: err.Error()}
-		}
+	if err := m.validator.validate(ctx, req, valuePath{}, &validationErrs); err != nil {
+		return reflect.Value{}, err
 	}
 
-	res := m.Func.Call([]reflect.Value{reflect.ValueOf(m.Method), reflect.ValueOf(ctx), req})
+	if len(validationErrs) > 0 {
+		return reflect.Value{}, newValidationError(validationErrs)
+	}
 
-	if !res[1].IsNil() {
-		return nil, res[1].Interface().(error)
+	if m.Request.Kind() != reflect.Ptr {
+		return req.Elem(), nil
 	}
 
-	return res[0].Interface(), nil
+	return req, nil
+}
+
+// decodeError returns VALIDATION_ERROR for the values of the wrong types and INVALID_JSON for the rest errors.
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeError(typeErr)
+	}
+
+	return &Error{Code: "INVALID_JSON", Message: err.Error()}
 }
 
 func checkFileField(partName string, t reflect.Type) (string, bool) {
+	if t.Kind() != reflect.Struct {
+		return "", false
+	}
+
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 		if partName == field.Tag.Get("json") && t.Field(i).Type.Implements(reflect.TypeOf((*File)(nil)).Elem()) {
@@ -254,37 +487,3 @@ func checkFileField(partName string, t reflect.Type) (string, bool) {
 	}
 	return "", false
 }
-
-func (m *MethodDesc) validateData(data reflect.Value, curPath string) error {
-	switch data.Type().Kind() {
-	case reflect.Ptr:
-		if !data.IsNil() {
-			return m.validateData(data.Elem(), curPath)
-		}
-
-	case reflect.Struct:
-		for i := 0; i < data.NumField(); i++ {
-			fieldVal := data.Field(i)
-			fieldType := data.Type().Field(i)
-			ft := fieldType.Type
-			if ft.Kind() == reflect.Ptr {
-				ft = ft.Elem()
-			}
-
-			if ft.Kind() == reflect.Struct {
-				if err := m.validateData(fieldVal, curPath+"/"+fieldType.Name); err != nil {
-					return err
-				}
-				continue
-			}
-
-			for _, validate := range m.Validators[curPath+"/"+fieldType.Name] {
-				if err := validate(fieldVal.Interface()); err != nil {
-					return err
-				}
-			}
-		}
-	}
-
-	return nil
-}
@@ -0,0 +1,221 @@
+package rpc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type mount struct {
+	prefix string
+	child  *Rpc
+}
+
+// Mount serves the child methods under the prefix, e.g. "/partner".
+// The child options which are not set are inherited from the parent.
+// The in-flight calls are tracked by both the Rpc which serves the HTTP request and the child,
+// so the Shutdown of either of them drains the mounted methods.
+func (r *Rpc) Mount(prefix string, child *Rpc) error {
+	prefix = "/" + strings.Trim(prefix, "/")
+	if prefix == "/" {
+		return fmt.Errorf("empty mount prefix")
+	}
+
+	if child == r {
+		return fmt.Errorf("cannot mount the Rpc into itself")
+	}
+
+	r.registry.mu.Lock()
+
+	if child.parent != nil {
+		r.registry.mu.Unlock()
+		return fmt.Errorf("the Rpc is already mounted")
+	}
+
+	mounts := r.registry.loadMounts()
+	for _, m := range mounts {
+		if m.prefix == prefix {
+			r.registry.mu.Unlock()
+			return fmt.Errorf("prefix %s is already mounted", prefix)
+		}
+	}
+
+	var problems []error
+	for _, path := range child.GetPaths() {
+		md := r.registry.load()[prefix+path]
+		if md == nil {
+			md = r.conflict(prefix+path, nil)
+		}
+		if md != nil {
+			problems = append(problems, fmt.Errorf("path %s is already registered by %s", prefix+path, methodName(md.Method)))
+		}
+	}
+	if len(problems) > 0 {
+		r.registry.mu.Unlock()
+		return &RegistrationError{Method: "mounted to " + prefix, Problems: problems}
+	}
+
+	child.parent, child.prefix = r, prefix
+	newMounts := append(append(make([]mount, 0, len(mounts)+1), mounts...), mount{prefix, child})
+	sort.Slice(newMounts, func(i, j int) bool {
+		return newMounts[i].prefix > newMounts[j].prefix // longer prefixes go first
+	})
+	r.registry.mounts.Store(newMounts)
+
+	var events []Event
+	for _, path := range child.GetPaths() {
+		events = append(events, Event{Type: EventRegister, Path: prefix + path, Method: child.GetMethod(path)})
+	}
+	r.registry.commit(r.registry.load(), events)
+
+	r.registry.mu.Unlock()
+
+	// Outside of the parent lock, the child events are delivered under the child lock
+	child.Subscribe(func(e Event) {
+		e.Path = prefix + e.Path
+
+		r.registry.mu.Lock()
+		defer r.registry.mu.Unlock()
+
+		r.registry.commit(r.registry.load(), []Event{e})
+	})
+
+	return nil
+}
+
+// MountPrefix returns the full prefix the Rpc is mounted at, e.g. "/partner", empty if it's not mounted.
+func (r *Rpc) MountPrefix() string {
+	prefix := ""
+	for p := r; p.parent != nil; p = p.parent {
+		prefix = p.prefix + prefix
+	}
+
+	return prefix
+}
+
+func (reg *registry) loadMounts() []mount {
+	mounts, _ := reg.mounts.Load().([]mount)
+	return mounts
+}
+
+// lookup returns the Rpc owning the path and the method description, if it exists.
+func (r *Rpc) lookup(path string) (*Rpc, *MethodDesc) {
+	path = strings.TrimSuffix(path, "/")
+
+	if md := r.registry.load()[path]; md != nil {
+		return r, md
+	}
+
+	for _, m := range r.registry.loadMounts() {
+		if strings.HasPrefix(path, m.prefix+"/") {
+			return m.child.lookup(strings.TrimPrefix(path, m.prefix))
+		}
+	}
+
+	return r, nil
+}
+
+// mountedMethod returns the method registered at the path by the mounted Rpcs except the skipped one.
+// All the matching prefixes are checked, not only the longest one serving the path.
+func (r *Rpc) mountedMethod(path string, skip *Rpc) *MethodDesc {
+	for _, m := range r.registry.loadMounts() {
+		if m.child == skip || !strings.HasPrefix(path, m.prefix+"/") {
+			continue
+		}
+
+		childPath := strings.TrimPrefix(path, m.prefix)
+		if md := m.child.registry.load()[childPath]; md != nil {
+			return md
+		}
+		if md := m.child.mountedMethod(childPath, nil); md != nil {
+			return md
+		}
+	}
+
+	return nil
+}
+
+// conflict returns the method registered at the path of the Rpc by its mounts except the skipped one
+// or by its parents, nil if the path is free.
+func (r *Rpc) conflict(path string, skip *Rpc) *MethodDesc {
+	if md := r.mountedMethod(path, skip); md != nil {
+		return md
+	}
+
+	for c, p := r, r.parent; p != nil; c, p = p, p.parent {
+		path = c.prefix + path
+		if md := p.registry.load()[path]; md != nil {
+			return md
+		}
+		if md := p.mountedMethod(path, c); md != nil {
+			return md
+		}
+	}
+
+	return nil
+}
+
+// allMethods returns the methods including the mounted ones by the full path.
+func (r *Rpc) allMethods() map[string]*MethodDesc {
+	res := map[string]*MethodDesc{}
+
+	mounts := r.registry.loadMounts()
+	for i := len(mounts) - 1; i >= 0; i-- {
+		for path, md := range mounts[i].child.allMethods() {
+			res[mounts[i].prefix+path] = md
+		}
+	}
+
+	for path, md := range r.registry.load() {
+		res[path] = md
+	}
+
+	return res
+}
+
+// effectiveOptions returns the options with the unset values inherited from the parents.
+func (r *Rpc) effectiveOptions() opts {
+	res := r.options
+	for p := r.parent; p != nil; p = p.parent {
+		if res.cors == nil {
+			res.cors = p.options.cors
+		}
+		if res.maxMemory == 0 {
+			res.maxMemory = p.options.maxMemory
+		}
+		if res.retryAfter == 0 {
+			res.retryAfter = p.options.retryAfter
+		}
+		if res.translator == nil {
+			res.translator = p.options.translator
+		}
+		res.emptyObject = res.emptyObject || p.options.emptyObject
+	}
+
+	if res.retryAfter == 0 {
+		res.retryAfter = time.Second
+	}
+
+	return res
+}
+
+func (r *Rpc) globalLimiter() *limiter {
+	for p := r; p != nil; p = p.parent {
+		if p.limiter != nil {
+			return p.limiter
+		}
+	}
+
+	return nil
+}
+
+func (r *Rpc) isDrainingFrom(entry *Rpc) bool {
+	for p := r; p != nil && p != entry; p = p.parent {
+		if p.IsDraining() {
+			return true
+		}
+	}
+
+	return false
+}
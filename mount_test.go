@@ -0,0 +1,208 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-qbit/rpc"
+	mHello "github.com/go-qbit/rpc/internal/test/method/hello"
+	mSleep "github.com/go-qbit/rpc/internal/test/method/sleep"
+)
+
+func TestRpc_Mount(t *testing.T) {
+	parent := rpc.New("github.com/go-qbit/rpc/internal/test/method",
+		rpc.WithCorsV2([]string{"https://example.com"}, []string{"Content-Type"}, []string{"POST"}, "60"),
+	)
+	if err := parent.RegisterMethod(mHello.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	child := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := parent.Mount("/internal", child); err != nil {
+		t.Fatal(err)
+	}
+	if err := parent.Mount("/other", child); err == nil {
+		t.Fatalf("Expected an error for the mounted Rpc")
+	}
+
+	version := parent.Version()
+	if err := child.RegisterMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+	if parent.Version() == version {
+		t.Fatalf("The parent version is not changed after the child registration")
+	}
+
+	if m := parent.GetMethod("/internal/sleep/v1"); m == nil || m.Path != "/sleep/v1" {
+		t.Fatalf("Invalid mounted method %v", m)
+	}
+
+	srv := httptest.NewServer(parent)
+	defer srv.Close()
+
+	resp, err := srv.Client().Post(srv.URL+"/internal/sleep/v1", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid status code = %d, expected 200", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodOptions, srv.URL+"/internal/sleep/v1", nil)
+	resp, err = srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if origin := resp.Header.Get("Access-Control-Allow-Origin"); origin != "https://example.com" {
+		t.Fatalf("Invalid inherited CORS origin '%s'", origin)
+	}
+
+	merged := parent.GetSwagger(context.Background())
+	if _, exists := merged.Paths["/internal/sleep/v1"]; !exists {
+		t.Fatalf("No mounted path in the merged OpenAPI")
+	}
+	if _, exists := merged.Paths["/hello/v1"]; !exists {
+		t.Fatalf("No parent path in the merged OpenAPI")
+	}
+
+	group := child.GetSwagger(context.Background())
+	if len(group.Paths) != 1 {
+		t.Fatalf("Invalid group OpenAPI paths number = %d, expected 1", len(group.Paths))
+	}
+}
+
+func TestRpc_Mount_Shutdown(t *testing.T) {
+	parent := rpc.New("")
+	child := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := child.RegisterMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+	if err := parent.Mount("/internal", child); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(parent)
+	defer srv.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp, err := srv.Client().Post(srv.URL+"/internal/sleep/v1", "application/json", strings.NewReader(`{"milliseconds": 200}`))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	}()
+
+	waitActiveCalls(t, child, 1)
+	if calls := child.ActiveCalls(); calls[0].Method != "/sleep/v1" {
+		t.Fatalf("Invalid child active call %+v", calls[0])
+	}
+	if calls := parent.ActiveCalls(); len(calls) != 1 || calls[0].Method != "/internal/sleep/v1" {
+		t.Fatalf("Invalid parent active calls %+v", calls)
+	}
+
+	start := time.Now()
+	if err := child.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Fatalf("The child Shutdown returned after %s before the call is finished", d)
+	}
+	<-done
+
+	if len(parent.ActiveCalls()) != 0 {
+		t.Fatalf("The parent call is not finished")
+	}
+}
+
+func TestRpc_Mount_Conflicts(t *testing.T) {
+	handler := func(ctx context.Context, req *struct{}) (*struct{}, error) {
+		return &struct{}{}, nil
+	}
+
+	parent := rpc.New("")
+	if err := rpc.Handle(parent, "/x/sleep/v1", handler); err != nil {
+		t.Fatal(err)
+	}
+
+	child := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := child.RegisterMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	var regErr *rpc.RegistrationError
+	if err := parent.Mount("/x", child); !errors.As(err, &regErr) || !strings.Contains(err.Error(), "/x/sleep/v1") {
+		t.Fatalf("Invalid error %v, expected the conflict of /x/sleep/v1", err)
+	}
+	if err := parent.Mount("/y", child); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rpc.Handle(parent, "/y/sleep/v1", handler); !errors.As(err, &regErr) {
+		t.Fatalf("Invalid error %v, expected the conflict with the mounted method", err)
+	}
+	if err := rpc.Handle(child, "/x/sleep/v1", handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := rpc.Handle(parent, "/y/other/v1", handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := rpc.Handle(child, "/other/v1", handler); !errors.As(err, &regErr) {
+		t.Fatalf("Invalid error %v, expected the conflict with the parent method", err)
+	}
+	if err := child.ReplaceMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	group := child.GetSwagger(context.Background())
+	if len(group.Servers) != 1 || group.Servers[0].Url != "/y" {
+		t.Fatalf("Invalid group OpenAPI servers %+v", group.Servers)
+	}
+	if child.MountPrefix() != "/y" || parent.MountPrefix() != "" {
+		t.Fatalf("Invalid mount prefixes '%s', '%s'", child.MountPrefix(), parent.MountPrefix())
+	}
+	if servers := parent.GetSwagger(context.Background()).Servers; len(servers) != 0 {
+		t.Fatalf("Invalid root OpenAPI servers %+v", servers)
+	}
+}
+
+func TestRpc_Mount_Version(t *testing.T) {
+	var childErrors struct {
+		CHILD_ERROR rpc.ErrorFunc `desc:"The child error"`
+	}
+	var parentErrors struct {
+		PARENT_ERROR rpc.ErrorFunc `desc:"The parent error"`
+	}
+
+	parent, child := rpc.New(""), rpc.New("")
+	if err := parent.Mount("/child", child); err != nil {
+		t.Fatal(err)
+	}
+
+	// The parent documents the shared errors of the mounted methods
+	parentVersion := parent.Version()
+	if err := child.RegisterErrors(&childErrors); err != nil {
+		t.Fatal(err)
+	}
+	if parent.Version() == parentVersion {
+		t.Fatal("The parent version is not changed by the child errors")
+	}
+
+	// The child documents the shared errors of the parent
+	childVersion := child.Version()
+	if err := parent.RegisterErrors(&parentErrors); err != nil {
+		t.Fatal(err)
+	}
+	if child.Version() == childVersion {
+		t.Fatal("The child version is not changed by the parent errors")
+	}
+}
@@ -3,6 +3,10 @@
 // The OpenApi struct can be serialized in JSON and YAML formats.
 package openapi
 
+import (
+	"encoding/json"
+)
+
 type OpenApi struct {
 	Openapi    string                  `json:"openapi" yaml:"openapi"`
 	Info       Info                    `json:"info" yaml:"info"`
@@ -10,6 +14,18 @@ type OpenApi struct {
 	Paths      map[string]Path         `json:"paths" yaml:"paths"`
 	Components Components              `json:"components" yaml:"components"`
 	Security   []map[string][]struct{} `json:"security,omitempty" yaml:"security,omitempty"`
+	Tags       []Tag                   `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+type Tag struct {
+	Name         string        `json:"name" yaml:"name"`
+	Description  string        `json:"description,omitempty" yaml:"description,omitempty"`
+	ExternalDocs *ExternalDocs `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+}
+
+type ExternalDocs struct {
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Url         string `json:"url" yaml:"url"`
 }
 
 type Info struct {
@@ -32,8 +48,37 @@ type Operation struct {
 	Description string                  `json:"description,omitempty" yaml:"description,omitempty"`
 	OperationId string                  `json:"operationId" yaml:"operationId"`
 	Tags        []string                `json:"tags,omitempty" yaml:"tags,omitempty"`
-	RequestBody RequestBody             `json:"requestBody" yaml:"requestBody"`
+	RequestBody *RequestBody            `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
 	Responses   map[string]ResponseBody `json:"responses" yaml:"responses"`
+
+	ExternalDocs *ExternalDocs `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+
+	// Extensions are the vendor x- fields of the operation
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type operation Operation
+
+	data, err := json.Marshal(operation(o))
+	if err != nil || len(o.Extensions) == 0 {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for name, value := range o.Extensions {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = raw
+	}
+
+	return json.Marshal(fields)
 }
 
 type RequestBody struct {
@@ -44,9 +89,15 @@ type RequestBody struct {
 
 type ResponseBody struct {
 	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Headers     map[string]Header  `json:"headers,omitempty" yaml:"headers,omitempty"`
 	Content     map[string]Content `json:"content,omitempty" yaml:"content,omitempty"`
 }
 
+type Header struct {
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Schema      Schema `json:"schema" yaml:"schema"`
+}
+
 type Content struct {
 	Schema Schema `json:"schema" yaml:"schema"`
 }
@@ -57,10 +108,33 @@ type Schema struct {
 	Type        string            `json:"type,omitempty" yaml:"type,omitempty"`
 	Format      string            `json:"format,omitempty" yaml:"format,omitempty"`
 	Properties  map[string]Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required    []string          `json:"required,omitempty" yaml:"required,omitempty"`
 	Items       *Schema           `json:"items,omitempty" yaml:"items,omitempty"`
 	Minimum     interface{}       `json:"minimum,omitempty" yaml:"minimum,omitempty"`
 	Maximum     interface{}       `json:"maximum,omitempty" yaml:"maximum,omitempty"`
 	Pattern     string            `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+
+	ExclusiveMinimum bool        `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum bool        `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	MultipleOf       interface{} `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	MinLength        *int64      `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength        *int64      `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinItems         *int64      `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems         *int64      `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	UniqueItems      bool        `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+
+	Enum    []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default interface{}   `json:"default,omitempty" yaml:"default,omitempty"`
+
+	OneOf         []Schema       `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	Discriminator *Discriminator `json:"discriminator,omitempty" yaml:"discriminator,omitempty"`
+}
+
+type Discriminator struct {
+	PropertyName string            `json:"propertyName" yaml:"propertyName"`
+	Mapping      map[string]string `json:"mapping,omitempty" yaml:"mapping,omitempty"`
 }
 
 type SecurityScheme struct {
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// PathsChangedError is returned by CheckPaths if the public paths differ from the snapshot.
+type PathsChangedError struct {
+	Added   []string
+	Removed []string
+}
+
+func (e *PathsChangedError) Error() string {
+	b := &strings.Builder{}
+	b.WriteString("the public paths are changed")
+	for _, path := range e.Added {
+		b.WriteString("\n  + ")
+		b.WriteString(path)
+	}
+	for _, path := range e.Removed {
+		b.WriteString("\n  - ")
+		b.WriteString(path)
+	}
+
+	return b.String()
+}
+
+// WritePaths writes the snapshot of the public paths, one path per line.
+func (r *Rpc) WritePaths(w io.Writer) error {
+	for _, path := range r.GetPublicPaths() {
+		if _, err := io.WriteString(w, path+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckPaths compares the public paths with the snapshot written by WritePaths.
+// Empty lines and lines starting with # are ignored.
+func (r *Rpc) CheckPaths(snapshot io.Reader) error {
+	expected := map[string]bool{}
+
+	scanner := bufio.NewScanner(snapshot)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		expected[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("cannot read paths snapshot: %w", err)
+	}
+
+	res := &PathsChangedError{}
+	for _, path := range r.GetPublicPaths() {
+		if expected[path] {
+			delete(expected, path)
+			continue
+		}
+		res.Added = append(res.Added, path)
+	}
+
+	for path := range expected {
+		res.Removed = append(res.Removed, path)
+	}
+	sort.Strings(res.Removed)
+
+	if len(res.Added) > 0 || len(res.Removed) > 0 {
+		return res
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package rpc_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mHello "github.com/go-qbit/rpc/internal/test/method/hello"
+	mUsers "github.com/go-qbit/rpc/internal/test/method/users"
+)
+
+func newPathsRpc(t *testing.T) *rpc.Rpc {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethods(mHello.New(), mUsers.NewGet(), mUsers.NewList()); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestRpc_CheckPaths(t *testing.T) {
+	r := newPathsRpc(t)
+
+	snapshot, err := os.Open("testdata/paths.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.Close()
+
+	if err := r.CheckPaths(snapshot); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRpc_CheckPaths_Changed(t *testing.T) {
+	r := newPathsRpc(t)
+
+	err := r.CheckPaths(bytes.NewBufferString("/hello/v1\n/hello/v2\n/hello/v3\n/users/get/v1\n/users/delete/v1\n"))
+
+	var changedErr *rpc.PathsChangedError
+	if !errors.As(err, &changedErr) {
+		t.Fatalf("Expected *rpc.PathsChangedError, got %v", err)
+	}
+
+	if len(changedErr.Added) != 1 || changedErr.Added[0] != "/users/list/v1" {
+		t.Fatalf("Invalid added paths %v", changedErr.Added)
+	}
+
+	if len(changedErr.Removed) != 1 || changedErr.Removed[0] != "/users/delete/v1" {
+		t.Fatalf("Invalid removed paths %v", changedErr.Removed)
+	}
+}
@@ -0,0 +1,69 @@
+package rpc_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mPing "github.com/go-qbit/rpc/internal/test/method/ping"
+)
+
+func TestRpc_ServeHTTP_NoRequestNoResponse(t *testing.T) {
+	for _, tc := range []struct {
+		options []rpc.OptsFunc
+		path    string
+		body    string
+		status  int
+		resp    string
+	}{
+		{nil, "/ping/v1", "", http.StatusOK, ""},
+		{nil, "/ping/v2", `{"message": "hello"}`, http.StatusNoContent, ""},
+		{nil, "/ping/v3", "", http.StatusNoContent, ""},
+		{[]rpc.OptsFunc{rpc.WithEmptyObjectResponse()}, "/ping/v3", "", http.StatusOK, "{}\n"},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			r := rpc.New("github.com/go-qbit/rpc/internal/test/method", tc.options...)
+			if err := r.RegisterMethod(mPing.New()); err != nil {
+				t.Fatal(err)
+			}
+
+			srv := httptest.NewServer(r)
+			defer srv.Close()
+
+			resp, err := srv.Client().Post(srv.URL+tc.path, "application/json", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			data, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tc.status {
+				t.Fatalf("Invalid status code = %d, expected %d. Data: '%s'", resp.StatusCode, tc.status, data)
+			}
+			if tc.resp != "" && string(data) != tc.resp {
+				t.Fatalf("Invalid response '%s', expected '%s'", data, tc.resp)
+			}
+		})
+	}
+}
+
+func TestRpc_GetSwagger_NoRequestNoResponse(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mPing.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	swagger := r.GetSwagger(context.Background())
+
+	if swagger.Paths["/ping/v1"].Post.RequestBody != nil {
+		t.Fatalf("Unexpected request body for the method without request")
+	}
+
+	if _, exists := swagger.Paths["/ping/v3"].Post.Responses["204"]; !exists {
+		t.Fatalf("No 204 response for the method without response")
+	}
+}
@@ -0,0 +1,274 @@
+package rpc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+type EventType int
+
+const (
+	EventRegister EventType = iota
+	EventUnregister
+	EventReplace
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventRegister:
+		return "register"
+	case EventUnregister:
+		return "unregister"
+	case EventReplace:
+		return "replace"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Event describes a change of the registered methods set.
+// Method is the new method description for EventRegister and EventReplace and the removed one for EventUnregister.
+type Event struct {
+	Type   EventType
+	Path   string
+	Method *MethodDesc
+}
+
+// registry is a copy-on-write storage of the methods. Readers never lock,
+// writers are serialized by mu and store a new map.
+type registry struct {
+	mu             sync.Mutex
+	methods        atomic.Value // map[string]*MethodDesc
+	mounts         atomic.Value // []mount
+	catalog        atomic.Value // errorCatalog
+	version        uint64
+	subscribers    map[int]func(Event)
+	lastSubscriber int
+}
+
+func (reg *registry) load() map[string]*MethodDesc {
+	return reg.methods.Load().(map[string]*MethodDesc)
+}
+
+func (reg *registry) copy() map[string]*MethodDesc {
+	cur := reg.load()
+	res := make(map[string]*MethodDesc, len(cur))
+	for path, md := range cur {
+		res[path] = md
+	}
+
+	return res
+}
+
+func (reg *registry) commit(methods map[string]*MethodDesc, events []Event) {
+	reg.methods.Store(methods)
+	atomic.AddUint64(&reg.version, 1)
+
+	for _, e := range events {
+		for _, f := range reg.subscribers {
+			f(e)
+		}
+	}
+}
+
+func (r *Rpc) RegisterMethods(methods ...Method) error {
+	for _, m := range methods {
+		if err := r.RegisterMethod(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Rpc) RegisterMethod(method Method) error {
+	return r.updateMethod(method, false)
+}
+
+// ReplaceMethod atomically replaces all the versions of the previously registered method of the same type.
+// The versions missed in the new method are unregistered.
+func (r *Rpc) ReplaceMethod(method Method) error {
+	return r.updateMethod(method, true)
+}
+
+// UnregisterMethod removes all the versions of the method of the same type.
+func (r *Rpc) UnregisterMethod(method Method) error {
+	r.registry.mu.Lock()
+	defer r.registry.mu.Unlock()
+
+	methods := r.registry.copy()
+
+	events := removeMethods(methods, method)
+	if len(events) == 0 {
+		return fmt.Errorf("method %T is not registered", method)
+	}
+
+	r.registry.commit(methods, events)
+
+	return nil
+}
+
+func (r *Rpc) updateMethod(method Method, replace bool) error {
+	mds, problems := descsFromMethod(method, r.trimPrefix)
+
+	var methodLimiter *limiter
+	if l, ok := method.(ConcurrencyLimiter); ok {
+		methodLimiter = newLimiter(l.ConcurrencyLimit())
+	}
+	for _, md := range mds {
+		md.limiter = methodLimiter
+	}
+
+	return r.addDescs(method, mds, problems, replace, func(methods map[string]*MethodDesc) ([]errorBinding, []error) {
+		return bindErrors(method, r.trimPrefix, methods)
+	})
+}
+
+// addDescs adds the method descriptions to the registry if there are no problems.
+// The bind function is called with the new methods set, its bindings are set just before the commit.
+func (r *Rpc) addDescs(method Method, mds []*MethodDesc, problems []error, replace bool, bind func(map[string]*MethodDesc) ([]errorBinding, []error)) error {
+	r.registry.mu.Lock()
+	defer r.registry.mu.Unlock()
+
+	methods := r.registry.copy()
+
+	var removed []Event
+	if replace {
+		removed = removeMethods(methods, method)
+	}
+
+	for _, md := range mds {
+		if existing := methods[md.Path]; existing != nil {
+			problems = append(problems, fmt.Errorf("path %s is already registered by %s", md.Path, methodName(existing.Method)))
+			continue
+		}
+		if existing := r.conflict(md.Path, nil); existing != nil {
+			problems = append(problems, fmt.Errorf("path %s conflicts with %s of the mounted or parent Rpc", md.Path, methodName(existing.Method)))
+			continue
+		}
+
+		if limit, exists := r.options.methodLimits[md.Path]; exists {
+			md.limiter = newLimiter(limit)
+		}
+
+		methods[md.Path] = md
+	}
+
+	bindings, bindProblems := bind(methods)
+	problems = append(problems, bindProblems...)
+
+	if len(problems) > 0 {
+		return &RegistrationError{Method: methodName(method), Problems: problems}
+	}
+
+	setErrorFuncs(bindings)
+
+	var events []Event
+	for _, md := range mds {
+		eventType := EventRegister
+		for i, e := range removed {
+			if e.Path == md.Path {
+				eventType = EventReplace
+				removed = append(removed[:i], removed[i+1:]...)
+				break
+			}
+		}
+		events = append(events, Event{Type: eventType, Path: md.Path, Method: md})
+	}
+
+	r.registry.commit(methods, append(events, removed...))
+
+	return nil
+}
+
+// removeMethods removes all the versions of the method. The methods are matched by type,
+// except the function-based ones which are matched by the path.
+func removeMethods(methods map[string]*MethodDesc, method Method) []Event {
+	var events []Event
+	for path, md := range methods {
+		if sameMethod(md.Method, method) {
+			delete(methods, path)
+			events = append(events, Event{Type: EventUnregister, Path: path, Method: md})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+
+	return events
+}
+
+func methodName(m Method) string {
+	if fm, ok := m.(*funcMethod); ok {
+		return "function " + fm.path
+	}
+
+	return fmt.Sprintf("%T", m)
+}
+
+func sameMethod(a, b Method) bool {
+	if fa, ok := a.(*funcMethod); ok {
+		fb, ok := b.(*funcMethod)
+		return ok && fa.path == fb.path
+	}
+
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+// Subscribe registers the function called synchronously on each change of the methods set.
+// The function must not register or unregister methods.
+// The returned function cancels the subscription.
+func (r *Rpc) Subscribe(f func(Event)) (unsubscribe func()) {
+	r.registry.mu.Lock()
+	defer r.registry.mu.Unlock()
+
+	if r.registry.subscribers == nil {
+		r.registry.subscribers = map[int]func(Event){}
+	}
+
+	r.registry.lastSubscriber++
+	id := r.registry.lastSubscriber
+	r.registry.subscribers[id] = f
+
+	return func() {
+		r.registry.mu.Lock()
+		defer r.registry.mu.Unlock()
+
+		delete(r.registry.subscribers, id)
+	}
+}
+
+// Version returns the number which is changed each time the methods set or the shared errors of the Rpc
+// or its parents are changed. It can be used to invalidate the data generated from the methods.
+func (r *Rpc) Version() uint64 {
+	var version uint64
+	for p := r; p != nil; p = p.parent {
+		version += atomic.LoadUint64(&p.registry.version)
+	}
+
+	return version
+}
+
+func (r *Rpc) GetPaths() []string {
+	methods := r.allMethods()
+
+	res := make([]string, 0, len(methods))
+
+	for path := range methods {
+		res = append(res, path)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+func (r *Rpc) GetMethod(path string) *MethodDesc {
+	_, md := r.lookup(path)
+
+	return md
+}
@@ -0,0 +1,170 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-qbit/rpc"
+	mHello "github.com/go-qbit/rpc/internal/test/method/hello"
+	mSleep "github.com/go-qbit/rpc/internal/test/method/sleep"
+)
+
+func TestRpc_ReplaceUnregisterMethod(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+
+	var events []rpc.Event
+	unsubscribe := r.Subscribe(func(e rpc.Event) {
+		events = append(events, e)
+	})
+	defer unsubscribe()
+
+	if err := r.RegisterMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+	version := r.Version()
+
+	if err := r.ReplaceMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+	if r.Version() == version {
+		t.Fatalf("The version is not changed after replacing")
+	}
+
+	if err := r.UnregisterMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+	if r.GetMethod("/sleep/v1") != nil {
+		t.Fatalf("The method is not unregistered")
+	}
+	if err := r.UnregisterMethod(mSleep.New()); err == nil {
+		t.Fatalf("Expected an error for the not registered method")
+	}
+
+	expected := []rpc.EventType{rpc.EventRegister, rpc.EventReplace, rpc.EventUnregister}
+	if len(events) != len(expected) {
+		t.Fatalf("Invalid events number = %d, expected %d", len(events), len(expected))
+	}
+	for i, e := range events {
+		if e.Type != expected[i] || e.Path != "/sleep/v1" {
+			t.Fatalf("Invalid event %d = %s %s, expected %s /sleep/v1", i, e.Type, e.Path, expected[i])
+		}
+	}
+}
+
+func TestRpc_RegisterMethod_Concurrent(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			resp, err := srv.Client().Post(srv.URL+"/sleep/v1", "application/json", strings.NewReader(`{}`))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			if err := r.ReplaceMethod(mHello.New()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(r.GetPaths()) != 4 {
+		t.Fatalf("Invalid paths %v", r.GetPaths())
+	}
+}
+
+// The method errors are returned by the calls while the method is replaced, run with -race.
+func TestRpc_ReplaceMethod_ErrorsRace(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mHello.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_, err := r.Invoke(ctx, "/hello/v1", []byte(`{"int_param": 150, "str_param": "str", "struct_param": {"f1": 1}, "with_err": true}`))
+				var rpcErr *rpc.Error
+				if !errors.As(err, &rpcErr) || rpcErr.Code != "Error1" {
+					t.Errorf("Invalid error %v, expected Error1", err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := r.ReplaceMethod(mHello.New()); err != nil {
+				t.Error(err)
+			}
+			if err := r.RegisterMethod(mHello.New()); err == nil {
+				t.Error("The duplicate registration succeeded")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRpc_Subscribe_RebuildSpec(t *testing.T) {
+	parent, child := rpc.New("github.com/go-qbit/rpc/internal/test/method"), rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := parent.Mount("/child", child); err != nil {
+		t.Fatal(err)
+	}
+
+	var events int
+	for _, r := range []*rpc.Rpc{parent, child} {
+		r := r
+		r.Subscribe(func(rpc.Event) {
+			r.GetSwagger(context.Background())
+			r.GetErrors()
+			events++
+		})
+	}
+
+	done := make(chan error)
+	go func() {
+		if err := parent.RegisterMethod(mHello.New()); err != nil {
+			done <- err
+			return
+		}
+		if err := child.RegisterMethod(mSleep.New()); err != nil {
+			done <- err
+			return
+		}
+		done <- parent.UnregisterMethod(mHello.New())
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The subscriber rebuilding the spec is deadlocked")
+	}
+
+	if events == 0 {
+		t.Fatal("No events")
+	}
+}
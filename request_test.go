@@ -0,0 +1,50 @@
+package rpc_test
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mUsers "github.com/go-qbit/rpc/internal/test/method/users"
+)
+
+func TestRpc_ServeHTTP_NonPointerRequest(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mUsers.NewDelete()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		path string
+		body string
+		resp string
+	}{
+		{"/users/delete/v1", `[1, 2, 3]`, "3\n"},
+		{"/users/delete/v2", `{"ids": [1, 2]}`, "2\n"},
+		{"/users/delete/v3", `42`, "42\n"},
+	} {
+		resp, err := srv.Client().Post(srv.URL+tc.path, "application/json", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 || string(data) != tc.resp {
+			t.Fatalf("Invalid response for %s = %d '%s', expected 200 '%s'", tc.path, resp.StatusCode, data, tc.resp)
+		}
+	}
+
+	swagger := r.GetSwagger(context.Background())
+	schema := swagger.Paths["/users/delete/v1"].Post.RequestBody.Content["application/json"].Schema
+	if schema.Type != "array" || schema.Items == nil || schema.Items.Type != "integer" {
+		t.Fatalf("Invalid request schema %+v", schema)
+	}
+}
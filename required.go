@@ -0,0 +1,181 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IsRequired reports whether the field is marked by the required:"true" tag,
+// so its JSON key must be present in the request.
+func IsRequired(f reflect.StructField) bool {
+	required, _ := strconv.ParseBool(f.Tag.Get("required"))
+	return required
+}
+
+// IsResponseOptional reports whether the JSON key of the response field may be absent or null,
+// i.e. the field is a pointer or has the omitempty option.
+func IsResponseOptional(f reflect.StructField) bool {
+	if f.Type.Kind() == reflect.Ptr {
+		return true
+	}
+
+	for _, option := range strings.Split(f.Tag.Get("json"), ",")[1:] {
+		if option == "omitempty" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requiredFields is the tree of the JSON keys which must be present in the request.
+type requiredFields struct {
+	keys   []string
+	nested []nestedRequired
+	items  *requiredFields // The fields of the array items or the map values
+}
+
+type nestedRequired struct {
+	key    string
+	fields *requiredFields
+}
+
+// getRequired returns the required keys tree of the type, nil if there are no required fields.
+func getRequired(t reflect.Type, curPath string, visited map[reflect.Type]bool) (*requiredFields, []error) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return getRequired(t.Elem(), curPath, visited)
+
+	case reflect.Slice, reflect.Array, reflect.Map:
+		items, problems := getRequired(t.Elem(), curPath+"[]", visited)
+		if items == nil {
+			return nil, problems
+		}
+
+		return &requiredFields{items: items}, problems
+
+	case reflect.Struct:
+		if visited[t] {
+			return nil, nil
+		}
+		visited[t] = true
+		defer delete(visited, t)
+
+		res := &requiredFields{}
+		var problems []error
+
+		addStructRequired(t, curPath, visited, res, &problems)
+
+		if len(res.keys) == 0 && len(res.nested) == 0 {
+			return nil, problems
+		}
+
+		return res, problems
+	}
+
+	return nil, nil
+}
+
+func addStructRequired(t reflect.Type, curPath string, visited map[reflect.Type]bool, res *requiredFields, problems *[]error) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, tagged := f.Name, false
+		if tag, exists := f.Tag.Lookup("json"); exists {
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name, tagged = tagName, true
+			}
+		}
+		if name == "-" || !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		if tag, exists := f.Tag.Lookup("required"); exists {
+			if _, err := strconv.ParseBool(tag); err != nil {
+				*problems = append(*problems, fmt.Errorf("%s/%s: invalid required tag: %w", curPath, f.Name, err))
+			}
+		}
+
+		// The fields of the embedded structs are at the same level in JSON
+		if ft := f.Type; f.Anonymous && !tagged {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructRequired(ft, curPath, visited, res, problems)
+				continue
+			}
+		}
+
+		if IsRequired(f) {
+			res.keys = append(res.keys, name)
+		}
+
+		nested, nestedProblems := getRequired(f.Type, curPath+"/"+f.Name, visited)
+		*problems = append(*problems, nestedProblems...)
+		if nested != nil {
+			res.nested = append(res.nested, nestedRequired{key: name, fields: nested})
+		}
+	}
+}
+
+// check adds the errors of the required keys missing in the JSON data to errs.
+// The data is not checked if it is not valid JSON, the decoder reports it.
+func (rf *requiredFields) check(data json.RawMessage, path valuePath, errs *[]ValidationError) {
+	if rf == nil {
+		return
+	}
+
+	if rf.items != nil {
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err == nil {
+			for i, item := range items {
+				rf.items.check(item, path.index(strconv.Itoa(i)), errs)
+			}
+			return
+		}
+
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(data, &values); err == nil {
+			keys := make([]string, 0, len(values))
+			for key := range values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				rf.items.check(values[key], path.index(key), errs)
+			}
+		}
+
+		return
+	}
+
+	// The null body or item has all the required keys missing
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return
+	}
+
+	for _, key := range rf.keys {
+		if _, exists := fields[key]; !exists {
+			*errs = append(*errs, path.field(key).error("required", ruleError("required", nil)))
+		}
+	}
+
+	// The null nested object is absent
+	for _, nested := range rf.nested {
+		if value, exists := fields[nested.key]; exists && !isNull(value) {
+			nested.fields.check(value, path.field(nested.key), errs)
+		}
+	}
+}
+
+func isNull(data json.RawMessage) bool {
+	return string(bytes.TrimSpace(data)) == "null"
+}
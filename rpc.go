@@ -4,28 +4,39 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"log"
 	"net/http"
 	"regexp"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-qbit/rpc/htb"
+	"github.com/go-qbit/rpc/openapi"
 )
 
 var boundaryRe = regexp.MustCompile(`;.*boundary=(.*)`)
 
 type Rpc struct {
 	trimPrefix string
-	methods    map[string]*MethodDesc
 	options    opts
+	calls      callTracker
+	limiter    *limiter
+	registry   registry
+	parent     *Rpc
+	prefix     string // The mount prefix in the parent
 }
 
 type opts struct {
-	cors      *cors
-	maxMemory int64
+	cors             *cors
+	maxMemory        int64
+	retryAfter       time.Duration
+	concurrencyLimit ConcurrencyLimit
+	methodLimits     map[string]ConcurrencyLimit
+	emptyObject      bool
+	translator       Translator
+	tags             []openapi.Tag
 }
 
 type cors struct {
@@ -77,79 +88,91 @@ func WithMaxMemory(size int64) OptsFunc {
 	}
 }
 
-func New(trimPrefix string, options ...OptsFunc) *Rpc {
-	computedOpts := opts{}
-	for _, f := range options {
-		f(&computedOpts)
+func WithRetryAfter(d time.Duration) OptsFunc {
+	return func(opts *opts) {
+		opts.retryAfter = d
 	}
+}
 
-	return &Rpc{
-		trimPrefix: trimPrefix,
-		methods:    map[string]*MethodDesc{},
-		options:    computedOpts,
+// WithConcurrencyLimit sets the limit for all the calls served by the Rpc.
+func WithConcurrencyLimit(limit ConcurrencyLimit) OptsFunc {
+	return func(opts *opts) {
+		opts.concurrencyLimit = limit
 	}
 }
 
-func (r *Rpc) RegisterMethods(methods ...Method) error {
-	for _, m := range methods {
-		if err := r.RegisterMethod(m); err != nil {
-			return err
+// WithMethodConcurrencyLimit sets the limit for the method path, e.g. "/report/v1".
+// It overrides the limit provided by the ConcurrencyLimiter interface.
+func WithMethodConcurrencyLimit(path string, limit ConcurrencyLimit) OptsFunc {
+	return func(opts *opts) {
+		if opts.methodLimits == nil {
+			opts.methodLimits = map[string]ConcurrencyLimit{}
 		}
+		opts.methodLimits[path] = limit
 	}
-
-	return nil
 }
 
-func (r *Rpc) RegisterMethod(method Method) error {
-	mds, err := descsFromMethod(method, r.trimPrefix)
-	if err != nil {
-		return fmt.Errorf("cannot register mehtod %T: %w", method, err)
-	}
-
-	for _, md := range mds {
-		r.methods[md.Path] = md
+// WithEmptyObjectResponse makes the methods without the response return 200 OK with {}
+// instead of 204 No Content.
+func WithEmptyObjectResponse() OptsFunc {
+	return func(opts *opts) {
+		opts.emptyObject = true
 	}
+}
 
-	if err := bindErrors(method, r.trimPrefix, r.methods); err != nil {
-		return fmt.Errorf("cannot bind errors for method %T: %w", method, err)
+// WithTranslator sets the translator of the validation and business errors messages
+// to the languages of the call, see Languages.
+func WithTranslator(t Translator) OptsFunc {
+	return func(opts *opts) {
+		opts.translator = t
 	}
-
-	r.GetSwagger(context.Background()) // Check swagger
-
-	return nil
 }
 
-func (r *Rpc) GetPaths() []string {
-	res := make([]string, 0, len(r.methods))
-
-	for path := range r.methods {
-		res = append(res, path)
+func New(trimPrefix string, options ...OptsFunc) *Rpc {
+	computedOpts := opts{}
+	for _, f := range options {
+		f(&computedOpts)
 	}
 
-	sort.Strings(res)
+	r := &Rpc{
+		trimPrefix: trimPrefix,
+		options:    computedOpts,
+		limiter:    newLimiter(computedOpts.concurrencyLimit),
+	}
+	r.registry.methods.Store(map[string]*MethodDesc{})
 
-	return res
+	return r
 }
 
-func (r *Rpc) GetMethod(path string) *MethodDesc {
-	path = strings.TrimSuffix(path, "/")
-
-	return r.methods[path]
+func (r *Rpc) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	r.serveHTTP(w, request, false)
 }
 
-func (r *Rpc) ServeHTTP(w http.ResponseWriter, request *http.Request) {
-	if r.options.cors != nil {
-		w.Header().Set("Access-Control-Allow-Origin", r.options.cors.allowOrigin)
-		w.Header().Set("Access-Control-Allow-Headers", r.options.cors.allowHeaders)
-		w.Header().Set("Access-Control-Allow-Methods", r.options.cors.allowMethods)
-		w.Header().Set("Access-Control-Max-Age", r.options.cors.maxAge)
+func (r *Rpc) serveHTTP(w http.ResponseWriter, request *http.Request, internal bool) {
+	path := strings.TrimSuffix(request.URL.Path, "/")
+	owner, method := r.lookup(path)
+	if method != nil && method.Visibility == Internal && !internal {
+		method = nil
 	}
+	options := owner.effectiveOptions()
 
-	if request.Method == http.MethodOptions && r.options.cors.allowOrigin != "" { // Cors
+	if options.cors != nil {
+		w.Header().Set("Access-Control-Allow-Origin", options.cors.allowOrigin)
+		w.Header().Set("Access-Control-Allow-Headers", options.cors.allowHeaders)
+		w.Header().Set("Access-Control-Allow-Methods", options.cors.allowMethods)
+		w.Header().Set("Access-Control-Max-Age", options.cors.maxAge)
+	}
+
+	if request.Method == http.MethodOptions && options.cors != nil && options.cors.allowOrigin != "" { // Cors
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
+	if path == "/_errors" && request.Method == http.MethodGet {
+		r.serveErrors(w, internal)
+		return
+	}
+
 	if request.Method != http.MethodPost {
 		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 		return
@@ -157,35 +180,49 @@ func (r *Rpc) ServeHTTP(w http.ResponseWriter, request *http.Request) {
 
 	defer request.Body.Close()
 
-	method := r.GetMethod(request.URL.Path)
 	if method == nil {
 		http.NotFound(w, request)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-
 	boundary := ""
 	subs := boundaryRe.FindStringSubmatch(request.Header.Get("Content-Type"))
 	if len(subs) > 0 {
 		boundary = subs[1]
 	}
 
-	resp, err := method.Call(request.Context(), request.Body, boundary, r.options.maxMemory)
+	resp, header, err := r.call(request.Context(), owner, path, method, options, request, request.Body, boundary, request.Header.Get("X-Request-Id"))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	copyHeader(w.Header(), header)
 	if err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			writeUnavailable(w, options)
+			return
+		}
+
 		if rpcErr, ok := err.(*Error); ok {
-			w.WriteHeader(http.StatusBadRequest)
-			if err := json.NewEncoder(w).Encode(rpcErr); err != nil {
-				log.Printf("Cannot marshal error response: %v", err)
+			status := http.StatusBadRequest
+			if rpcErr.Code == ErrorCodeOverloaded {
+				status = http.StatusServiceUnavailable
+				w.Header().Set("Retry-After", retryAfter(options))
 			}
+			writeError(w, status, rpcErr)
 			return
 		}
 
-		log.Printf("Cannot call %s: %v", method.Path, err)
+		log.Printf("Cannot call %s: %v", path, err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	if method.Response == nil {
+		if !options.emptyObject {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		resp = struct{}{}
+	}
+
 	var writer io.Writer = w
 	if CanGzipFast(request.Header.Get("Accept-Encoding")) {
 		w.Header().Set("Content-Encoding", "gzip")
@@ -202,3 +239,40 @@ func (r *Rpc) ServeHTTP(w http.ResponseWriter, request *http.Request) {
 		log.Printf("Cannot marshal response: %v", err)
 	}
 }
+
+// call runs the pipeline shared by ServeHTTP and Invoke: the draining check, the call tracking,
+// the concurrency limits and the method call. It returns the response headers set by the method.
+func (r *Rpc) call(ctx context.Context, owner *Rpc, path string, method *MethodDesc, options opts, request *http.Request, body io.Reader, boundary, requestID string) (interface{}, http.Header, error) {
+	if owner.isDrainingFrom(r) {
+		return nil, nil, ErrUnavailable
+	}
+
+	ctx, finish, ok := r.startCall(ctx, owner, method, requestID)
+	if !ok {
+		return nil, nil, ErrUnavailable
+	}
+	defer finish()
+
+	release, ok := owner.acquire(ctx, method)
+	if !ok {
+		return nil, nil, &Error{Code: ErrorCodeOverloaded, Message: "Too many concurrent calls"}
+	}
+	defer release()
+
+	ctx, cc := withCallContext(ctx, request, method)
+
+	resp, err := method.Call(ctx, body, boundary, options.maxMemory)
+	if rpcErr, ok := err.(*Error); ok && options.translator != nil {
+		err = translateError(ctx, options.translator, rpcErr)
+	}
+
+	return resp, cc.header, err
+}
+
+func writeError(w http.ResponseWriter, status int, rpcErr *Error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(rpcErr); err != nil {
+		log.Printf("Cannot marshal error response: %v", err)
+	}
+}
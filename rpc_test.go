@@ -8,6 +8,7 @@ import (
 	"mime/multipart"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/go-qbit/rpc"
@@ -136,8 +137,8 @@ func TestRpc_ServeHTTP_Validator_MinimumInt(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if resp.Code != "INVALID_JSON" {
-		t.Fatalf("Invalid error code field = '%s', expected 'INVALID_JSON'", resp.Code)
+	if resp.Code != "VALIDATION_ERROR" {
+		t.Fatalf("Invalid error code field = '%s', expected 'VALIDATION_ERROR'", resp.Code)
 	}
 }
 
@@ -157,8 +158,8 @@ func TestRpc_ServeHTTP_Validator_MinimumUint(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if resp.Code != "INVALID_JSON" {
-		t.Fatalf("Invalid error code field = '%s', expected 'INVALID_JSON'", resp.Code)
+	if resp.Code != "VALIDATION_ERROR" {
+		t.Fatalf("Invalid error code field = '%s', expected 'VALIDATION_ERROR'", resp.Code)
 	}
 }
 
@@ -178,8 +179,8 @@ func TestRpc_ServeHTTP_Validator_MaximumInt(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if resp.Code != "INVALID_JSON" {
-		t.Fatalf("Invalid error code field = '%s', expected 'INVALID_JSON'", resp.Code)
+	if resp.Code != "VALIDATION_ERROR" {
+		t.Fatalf("Invalid error code field = '%s', expected 'VALIDATION_ERROR'", resp.Code)
 	}
 }
 
@@ -199,8 +200,8 @@ func TestRpc_ServeHTTP_Validator_MaximumUint(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if resp.Code != "INVALID_JSON" {
-		t.Fatalf("Invalid error code field = '%s', expected 'INVALID_JSON'", resp.Code)
+	if resp.Code != "VALIDATION_ERROR" {
+		t.Fatalf("Invalid error code field = '%s', expected 'VALIDATION_ERROR'", resp.Code)
 	}
 }
 
@@ -221,8 +222,8 @@ func TestRpc_ServeHTTP_Validator_Pattern(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if resp.Code != "INVALID_JSON" {
-		t.Fatalf("Invalid error code field = '%s', expected 'INVALID_JSON'", resp.Code)
+	if resp.Code != "VALIDATION_ERROR" {
+		t.Fatalf("Invalid error code field = '%s', expected 'VALIDATION_ERROR'", resp.Code)
 	}
 }
 
@@ -260,3 +261,42 @@ func doPost(method string, req io.Reader, contentType string) (int, []byte) {
 
 	return resp.StatusCode, data
 }
+
+func TestRpc_ServeHTTP_TypedError(t *testing.T) {
+	status, data := doPost("/hello/v1", toJson(mHello.ReqV1{
+		IntParam:     100,
+		StrParam:     "test data",
+		StructParam:  mHello.StructV1{F1: 10},
+		WithTypedErr: true,
+	}), "application/json")
+	if status != 400 {
+		t.Fatalf("Invalid status code = %d, expected 400. Data: '%s'", status, data)
+	}
+
+	var resp struct {
+		Code string             `json:"code"`
+		Data mHello.ErrorDataV1 `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Code != "Error4" || resp.Data.Field != "with_typed_err" {
+		t.Fatalf("Invalid typed error '%s'", data)
+	}
+}
+
+func TestRpc_GetSwagger_TypedErrors(t *testing.T) {
+	swagger := testRpc.GetSwagger(context.Background())
+
+	schema := swagger.Paths["/hello/v1"].Post.Responses["400"].Content["application/json"].Schema
+	if schema.Discriminator == nil || schema.Discriminator.PropertyName != "code" {
+		t.Fatalf("No discriminator in the error schema")
+	}
+
+	ref := schema.Discriminator.Mapping["Error4"]
+	errSchema := swagger.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]
+	if errSchema.Properties["data"].Ref != "#/components/schemas/hello_errordatav1" {
+		t.Fatalf("Invalid Error4 data schema %+v", errSchema.Properties["data"])
+	}
+}
@@ -0,0 +1,43 @@
+package rpctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("rpctest.update", false, "update the golden files")
+
+// AssertGolden compares the data with the testdata/<name>.golden file. The JSON data is indented
+// before the comparison. Run the tests with -rpctest.update to write the golden files.
+func AssertGolden(t testing.TB, name string, data []byte) {
+	t.Helper()
+
+	if indented := (&bytes.Buffer{}); json.Indent(indented, data, "", "  ") == nil {
+		data = append(indented.Bytes(), '\n')
+	}
+
+	fileName := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("The data does not match %s:\n%s\nexpected:\n%s", fileName, data, expected)
+	}
+}
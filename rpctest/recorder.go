@@ -0,0 +1,109 @@
+package rpctest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// RecordedCall is the HTTP call captured by the Recorder.
+// The Response is decompressed if the handler has gzipped it.
+type RecordedCall struct {
+	Path     string
+	Request  []byte
+	Status   int
+	Response []byte
+	Duration time.Duration
+}
+
+// Recorder is the http.Handler capturing all the calls to the wrapped handler.
+type Recorder struct {
+	handler http.Handler
+	mu      sync.Mutex
+	calls   []RecordedCall
+}
+
+func NewRecorder(h http.Handler) *Recorder {
+	return &Recorder{handler: h}
+}
+
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reqData, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(reqData))
+
+	start := time.Now()
+	rw := httptest.NewRecorder()
+	rec.handler.ServeHTTP(rw, r)
+	duration := time.Since(start)
+
+	for name, values := range rw.Header() {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(rw.Code)
+	_, _ = w.Write(rw.Body.Bytes())
+
+	rec.mu.Lock()
+	rec.calls = append(rec.calls, RecordedCall{
+		Path:     r.URL.Path,
+		Request:  reqData,
+		Status:   rw.Code,
+		Response: decodeBody(rw.Header().Get("Content-Encoding"), rw.Body.Bytes()),
+		Duration: duration,
+	})
+	rec.mu.Unlock()
+}
+
+// Calls returns the captured calls in the order of their completion.
+func (rec *Recorder) Calls() []RecordedCall {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return append([]RecordedCall(nil), rec.calls...)
+}
+
+// CallsTo returns the captured calls of the method path.
+func (rec *Recorder) CallsTo(path string) []RecordedCall {
+	var res []RecordedCall
+	for _, c := range rec.Calls() {
+		if c.Path == path {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+func (rec *Recorder) Reset() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.calls = nil
+}
+
+// decodeBody returns the gzipped body decompressed, the rest bodies as is.
+func decodeBody(encoding string, body []byte) []byte {
+	if encoding != "gzip" {
+		return body
+	}
+
+	gzR, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return body
+	}
+	defer gzR.Close()
+
+	data, err := io.ReadAll(gzR)
+	if err != nil {
+		return body
+	}
+
+	return data
+}
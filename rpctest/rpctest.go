@@ -0,0 +1,209 @@
+// Package rpctest provides the utilities for testing the rpc methods and clients.
+package rpctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+)
+
+// Server is the test HTTP server serving the Rpc. All the calls are recorded.
+type Server struct {
+	*httptest.Server
+	*Recorder
+	Rpc *rpc.Rpc
+}
+
+func NewServer(r *rpc.Rpc) *Server {
+	recorder := NewRecorder(r)
+
+	return &Server{
+		Server:   httptest.NewServer(recorder),
+		Recorder: recorder,
+		Rpc:      r,
+	}
+}
+
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Err returns *rpc.Error for the business errors and the overloading, nil for the successful responses
+// and the generic error for the rest.
+func (r *Response) Err() error {
+	switch r.Status {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusBadRequest, http.StatusServiceUnavailable:
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			rpcErr := &rpc.Error{}
+			if err := json.Unmarshal(r.Body, rpcErr); err != nil {
+				return err
+			}
+			return rpcErr
+		}
+	}
+
+	return fmt.Errorf("%d %s: %s", r.Status, http.StatusText(r.Status), bytes.TrimSpace(r.Body))
+}
+
+func (s *Server) Post(path, contentType string, body io.Reader) (*Response, error) {
+	resp, err := s.Client().Post(s.URL+path, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   data,
+	}, nil
+}
+
+// PostJSON posts the request marshaled to JSON.
+func (s *Server) PostJSON(path string, req interface{}) (*Response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Post(path, "application/json", bytes.NewReader(data))
+}
+
+// File is the file uploaded in the multipart request. Field is the json name of the rpc.File field.
+type File struct {
+	Field   string
+	Name    string
+	Content []byte
+}
+
+// Multipart returns the multipart/form-data body with the request as the JSON data and the files,
+// and its content type.
+func Multipart(req interface{}, files ...File) (io.Reader, string, error) {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := w.WriteField("json_data", string(data)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for _, f := range files {
+		fw, err := w.CreateFormFile(f.Field, f.Name)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := fw.Write(f.Content); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf, w.FormDataContentType(), nil
+}
+
+// Call calls the method by HTTP with the JSON request. The business errors are returned as *rpc.Error.
+// The response is nil for the methods without the response.
+func Call[Req, Resp any](s *Server, path string, req *Req) (*Resp, error) {
+	resp, err := s.PostJSON(path, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResponse[Resp](resp)
+}
+
+// Upload calls the method by HTTP with the multipart request containing the files.
+func Upload[Req, Resp any](s *Server, path string, req *Req, files ...File) (*Resp, error) {
+	body, contentType, err := Multipart(req, files...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.Post(path, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResponse[Resp](resp)
+}
+
+func decodeResponse[Resp any](resp *Response) (*Resp, error) {
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+
+	if resp.Status == http.StatusNoContent {
+		return nil, nil
+	}
+
+	res := new(Resp)
+	if err := json.Unmarshal(resp.Body, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// AssertError fails the test if the error is not the business error with the code.
+func AssertError(t testing.TB, err error, code string) {
+	t.Helper()
+
+	var rpcErr *rpc.Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("Invalid error %v, expected the business error %s", err, code)
+	}
+
+	if rpcErr.Code != code {
+		t.Fatalf("Invalid error code '%s', expected '%s'. Message: '%s'", rpcErr.Code, code, rpcErr.Message)
+	}
+}
+
+// ValidationErrors returns the entries of the VALIDATION_ERROR data, nil for the rest errors.
+func ValidationErrors(err error) []rpc.ValidationError {
+	var rpcErr *rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != rpc.ErrorCodeValidation {
+		return nil
+	}
+
+	if errs, ok := rpcErr.Data.([]rpc.ValidationError); ok {
+		return errs
+	}
+
+	data, err := json.Marshal(rpcErr.Data)
+	if err != nil {
+		return nil
+	}
+
+	var errs []rpc.ValidationError
+	if err := json.Unmarshal(data, &errs); err != nil {
+		return nil
+	}
+
+	return errs
+}
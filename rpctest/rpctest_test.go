@@ -0,0 +1,151 @@
+package rpctest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mHello "github.com/go-qbit/rpc/internal/test/method/hello"
+	mPing "github.com/go-qbit/rpc/internal/test/method/ping"
+	"github.com/go-qbit/rpc/rpctest"
+)
+
+func newServer(t *testing.T) *rpctest.Server {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethods(mHello.New(), mPing.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCall(t *testing.T) {
+	srv := newServer(t)
+
+	resp, err := rpctest.Call[mHello.ReqV1, mHello.RespV1](srv, "/hello/v1", &mHello.ReqV1{
+		IntParam:    150,
+		StrParam:    "str value",
+		StructParam: mHello.StructV1{F1: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "Hello, world" {
+		t.Fatalf("Invalid message '%s'", resp.Message)
+	}
+
+	_, err = rpctest.Call[mHello.ReqV1, mHello.RespV1](srv, "/hello/v1", &mHello.ReqV1{
+		IntParam:    150,
+		StrParam:    "str value",
+		StructParam: mHello.StructV1{F1: 10},
+		WithErr:     true,
+	})
+	rpctest.AssertError(t, err, "Error1")
+
+	_, err = rpctest.Call[mHello.ReqV1, mHello.RespV1](srv, "/hello/v1", &mHello.ReqV1{IntParam: 1})
+	rpctest.AssertError(t, err, "VALIDATION_ERROR")
+	if errs := rpctest.ValidationErrors(err); len(errs) != 3 || errs[0].JsonPointer != "/int_param" || errs[0].Rule != "minimum" {
+		t.Fatalf("Invalid validation errors %+v", errs)
+	}
+
+	noResp, err := rpctest.Call[mPing.ReqV2, struct{}](srv, "/ping/v2", &mPing.ReqV2{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if noResp != nil {
+		t.Fatalf("Invalid response %v, expected nil", noResp)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	srv := newServer(t)
+
+	resp, err := rpctest.Upload[mHello.ReqV3, mHello.RespV3](srv, "/hello/v3", &mHello.ReqV3{IntParam: 1},
+		rpctest.File{Field: "content", Name: "hello", Content: []byte("Hello world")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.IntParam != 1 || resp.ContentLength != 11 {
+		t.Fatalf("Invalid response %+v", resp)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	srv := newServer(t)
+
+	if _, err := srv.PostJSON("/ping/v3", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.PostJSON("/hello/v1", mHello.ReqV1{WithErr: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := srv.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Invalid calls number %d, expected 2", len(calls))
+	}
+	if calls[0].Path != "/ping/v3" || calls[0].Status != 204 {
+		t.Fatalf("Invalid call %+v", calls[0])
+	}
+
+	helloCalls := srv.CallsTo("/hello/v1")
+	if len(helloCalls) != 1 || helloCalls[0].Status != 400 {
+		t.Fatalf("Invalid calls %+v", helloCalls)
+	}
+
+	if !strings.Contains(string(helloCalls[0].Response), `"code":"VALIDATION_ERROR"`) {
+		t.Fatalf("Invalid response %s", helloCalls[0].Response)
+	}
+
+	srv.Reset()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/hello/v1", strings.NewReader(`{"int_param":150,"str_param":"str value","struct_param":{"f1":10}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Invalid Content-Encoding '%s'", resp.Header.Get("Content-Encoding"))
+	}
+	calls = srv.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("Invalid calls number %d, expected 1", len(calls))
+	}
+	var helloResp mHello.RespV1
+	if err := json.Unmarshal(calls[0].Response, &helloResp); err != nil || helloResp.Message != "Hello, world" {
+		t.Fatalf("Invalid recorded response %q: %v", calls[0].Response, err)
+	}
+
+	srv.Reset()
+	if len(srv.Calls()) != 0 {
+		t.Fatal("The calls are not reset")
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	srv := newServer(t)
+
+	resp, err := srv.PostJSON("/hello/v1", mHello.ReqV1{
+		IntParam:    150,
+		StrParam:    "str value",
+		StructParam: mHello.StructV1{F1: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rpctest.AssertGolden(t, "hello_v1", resp.Body)
+}
@@ -0,0 +1,219 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ActiveCall describes a call which is being processed by the Rpc.
+type ActiveCall struct {
+	Method    string
+	Start     time.Time
+	RequestID string
+}
+
+// ShutdownError is returned by Shutdown if some calls were still running at the deadline.
+type ShutdownError struct {
+	Err   error
+	Calls []ActiveCall
+}
+
+func (e *ShutdownError) Error() string {
+	calls := make([]string, 0, len(e.Calls))
+	for _, c := range e.Calls {
+		calls = append(calls, fmt.Sprintf("%s (request %s, started %s)", c.Method, c.RequestID, c.Start.Format(time.RFC3339)))
+	}
+
+	return fmt.Sprintf("%v, active calls: %s", e.Err, strings.Join(calls, ", "))
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+type activeCall struct {
+	ActiveCall
+	cancel context.CancelFunc
+}
+
+type callTracker struct {
+	mu       sync.Mutex
+	draining bool
+	lastID   uint64
+	calls    map[uint64]*activeCall
+	idle     chan struct{}
+}
+
+func (t *callTracker) start(ctx context.Context, method, requestID string) (context.Context, func(), bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return nil, nil, false
+	}
+
+	if t.calls == nil {
+		t.calls = map[uint64]*activeCall{}
+	}
+
+	t.lastID++
+	id := t.lastID
+
+	if requestID == "" {
+		requestID = strconv.FormatUint(id, 10)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	t.calls[id] = &activeCall{
+		ActiveCall: ActiveCall{
+			Method:    method,
+			Start:     time.Now(),
+			RequestID: requestID,
+		},
+		cancel: cancel,
+	}
+
+	return ctx, func() { t.finish(id) }, true
+}
+
+func (t *callTracker) finish(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, exists := t.calls[id]; exists {
+		c.cancel()
+		delete(t.calls, id)
+	}
+
+	if t.idle != nil && len(t.calls) == 0 {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+func (t *callTracker) snapshot() []ActiveCall {
+	t.mu.Lock()
+	res := make([]ActiveCall, 0, len(t.calls))
+	for _, c := range t.calls {
+		res = append(res, c.ActiveCall)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Start.Before(res[j].Start)
+	})
+
+	return res
+}
+
+// startCall tracks the call by the owner of the method and by each Rpc it's mounted to up to the entry one,
+// so the Shutdown of any of them waits for the call. Each Rpc tracks the method by its own path.
+func (r *Rpc) startCall(ctx context.Context, owner *Rpc, method *MethodDesc, requestID string) (context.Context, func(), bool) {
+	var finishes []func()
+	finish := func() {
+		for i := len(finishes) - 1; i >= 0; i-- {
+			finishes[i]()
+		}
+	}
+
+	path := method.Path
+	for p := owner; p != nil; p = p.parent {
+		var (
+			f  func()
+			ok bool
+		)
+		ctx, f, ok = p.calls.start(ctx, path, requestID)
+		if !ok {
+			finish()
+			return nil, nil, false
+		}
+		finishes = append(finishes, f)
+
+		if p == r {
+			break
+		}
+		path = p.prefix + path
+	}
+
+	return ctx, finish, true
+}
+
+// Shutdown stops accepting new calls and waits for the in-flight ones to finish.
+// New calls are rejected with 503 Service Unavailable and the Retry-After header.
+// If ctx is done before all calls are finished, the contexts of the remaining calls are canceled
+// and a *ShutdownError listing them is returned.
+func (r *Rpc) Shutdown(ctx context.Context) error {
+	t := &r.calls
+
+	t.mu.Lock()
+	t.draining = true
+	if len(t.calls) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+
+	case <-ctx.Done():
+		calls := t.snapshot()
+
+		t.mu.Lock()
+		for _, c := range t.calls {
+			c.cancel()
+		}
+		t.mu.Unlock()
+
+		return &ShutdownError{Err: ctx.Err(), Calls: calls}
+	}
+}
+
+// IsDraining reports whether Shutdown has been called.
+func (r *Rpc) IsDraining() bool {
+	r.calls.mu.Lock()
+	defer r.calls.mu.Unlock()
+
+	return r.calls.draining
+}
+
+// ActiveCalls returns the snapshot of the calls being processed, ordered by the start time.
+func (r *Rpc) ActiveCalls() []ActiveCall {
+	return r.calls.snapshot()
+}
+
+// InFlight returns the number of the calls being processed per method path.
+func (r *Rpc) InFlight() map[string]int {
+	res := map[string]int{}
+	for _, c := range r.calls.snapshot() {
+		res[c.Method]++
+	}
+
+	return res
+}
+
+func writeUnavailable(w http.ResponseWriter, options opts) {
+	w.Header().Set("Retry-After", retryAfter(options))
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
+
+// retryAfter returns the delay in the whole seconds rounded up, at least 1.
+func retryAfter(options opts) string {
+	seconds := int((options.retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return strconv.Itoa(seconds)
+}
@@ -0,0 +1,153 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-qbit/rpc"
+	mSleep "github.com/go-qbit/rpc/internal/test/method/sleep"
+)
+
+func newSleepServer(t *testing.T) (*rpc.Rpc, *httptest.Server) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mSleep.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	return r, srv
+}
+
+func waitActiveCalls(t *testing.T, r *rpc.Rpc, n int) {
+	for i := 0; i < 100; i++ {
+		if len(r.ActiveCalls()) == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected %d active calls, got %d", n, len(r.ActiveCalls()))
+}
+
+func TestRpc_Shutdown_Wait(t *testing.T) {
+	r, srv := newSleepServer(t)
+
+	done := make(chan int)
+	go func() {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/sleep/v1", strings.NewReader(`{"milliseconds": 200}`))
+		req.Header.Set("X-Request-Id", "req-1")
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+
+	waitActiveCalls(t, r, 1)
+
+	calls := r.ActiveCalls()
+	if calls[0].Method != "/sleep/v1" || calls[0].RequestID != "req-1" {
+		t.Fatalf("Invalid active call %+v", calls[0])
+	}
+
+	if n := r.InFlight()["/sleep/v1"]; n != 1 {
+		t.Fatalf("Invalid in-flight number = %d, expected 1", n)
+	}
+
+	shutdownErr := make(chan error)
+	go func() {
+		shutdownErr <- r.Shutdown(context.Background())
+	}()
+
+	for !r.IsDraining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	resp, err := srv.Client().Post(srv.URL+"/sleep/v1", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Invalid status code = %d, expected 503", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("No Retry-After header")
+	}
+
+	if status := <-done; status != http.StatusOK {
+		t.Fatalf("Invalid status code = %d, expected 200", status)
+	}
+
+	if err := <-shutdownErr; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRpc_Shutdown_Deadline(t *testing.T) {
+	r, srv := newSleepServer(t)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp, err := srv.Client().Post(srv.URL+"/sleep/v1", "application/json", strings.NewReader(`{"milliseconds": 10000}`))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	waitActiveCalls(t, r, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := r.Shutdown(ctx)
+
+	var shutdownErr *rpc.ShutdownError
+	if !errors.As(err, &shutdownErr) {
+		t.Fatalf("Expected *rpc.ShutdownError, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if len(shutdownErr.Calls) != 1 || shutdownErr.Calls[0].Method != "/sleep/v1" {
+		t.Fatalf("Invalid active calls %+v", shutdownErr.Calls)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("The active call was not canceled")
+	}
+}
+
+func TestRpc_Shutdown_RetryAfter(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		500 * time.Millisecond:  "1",
+		time.Second:             "1",
+		1500 * time.Millisecond: "2",
+		0:                       "1",
+	} {
+		r := rpc.New("github.com/go-qbit/rpc/internal/test/method", rpc.WithRetryAfter(d))
+		if err := r.RegisterMethod(mSleep.New()); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/sleep/v1", strings.NewReader(`{}`)))
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != expected {
+			t.Fatalf("Invalid response %d with Retry-After '%s' for %s, expected 503 with '%s'", w.Code, w.Header().Get("Retry-After"), d, expected)
+		}
+	}
+}
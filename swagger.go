@@ -4,13 +4,22 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"sort"
 	"strings"
 
 	"github.com/go-qbit/rpc/openapi"
 )
 
+// GetSwagger returns the OpenAPI document of the public methods.
 func (r *Rpc) GetSwagger(ctx context.Context) *openapi.OpenApi {
+	return r.getSwagger(ctx, false)
+}
+
+// GetInternalSwagger returns the OpenAPI document of all the methods including the Hidden and Internal ones.
+func (r *Rpc) GetInternalSwagger(ctx context.Context) *openapi.OpenApi {
+	return r.getSwagger(ctx, true)
+}
+
+func (r *Rpc) getSwagger(ctx context.Context, internal bool) *openapi.OpenApi {
 	res := &openapi.OpenApi{
 		Openapi: "3.0.3",
 		Info: openapi.Info{
@@ -26,24 +35,43 @@ func (r *Rpc) GetSwagger(ctx context.Context) *openapi.OpenApi {
 		},
 	}
 
-	type errorDescription struct {
-		Code        string
-		Description string
+	options := r.effectiveOptions()
+	res.Tags = options.tags
+
+	// The paths of the mounted group are relative to its prefix
+	if prefix := r.MountPrefix(); prefix != "" {
+		res.Servers = []openapi.Server{{Url: prefix}}
 	}
 
-	for path, method := range r.methods {
-		errors := make([]errorDescription, 0, len(method.Errors))
-		for code, description := range method.Errors {
-			errors = append(errors, errorDescription{code, description})
+	// The structs of the requests and of the responses, see setResponseRequired
+	requestTypes, responseTypes := map[string]reflect.Type{}, map[string]reflect.Type{}
+	r.collectStructs(reflect.TypeOf(Error{}), responseTypes)
+
+	for path, method := range r.allMethods() {
+		if method.Visibility != Public && !internal {
+			continue
+		}
+
+		errors := []ErrorDesc{builtinErrors[0]}
+		if method.Request != nil {
+			errors = append(errors, builtinErrors[2])
+		}
+		errors = append(errors, r.MethodErrors(path)...)
+
+		for _, e := range errors {
+			if e.DataType != nil {
+				r.collectStructs(e.DataType, responseTypes)
+			}
+		}
+		if method.Request != nil {
+			r.collectStructs(method.Request, requestTypes)
+		}
+		if method.Response != nil {
+			r.collectStructs(method.Response, responseTypes)
 		}
-		sort.Slice(errors, func(i, j int) bool {
-			return errors[i].Code < errors[j].Code
-		})
 
 		errorsDescription := "### The business logic error\nPossible codes:\n"
-		for _, e := range append([]errorDescription{
-			{"INVALID_JSON", "Cannot parse JSON"},
-		}, errors...) {
+		for _, e := range errors {
 			errorsDescription += "* **" + e.Code + "**"
 			if e.Description != "" {
 				errorsDescription += ": " + e.Description
@@ -51,53 +79,228 @@ func (r *Rpc) GetSwagger(ctx context.Context) *openapi.OpenApi {
 			errorsDescription += "\n"
 		}
 
-		requestContentType := "application/json"
-		t := method.Request.Elem()
-		for i := 0; i < t.NumField(); i++ {
-			if t.Field(i).Type == reflect.TypeOf((*File)(nil)).Elem() {
-				requestContentType = "multipart/form-data"
-				break
-			}
-		}
+		operationId := strings.Replace(path[1:], "/", "_", -1)
 
-		res.Paths[path] = openapi.Path{
-			Post: openapi.Operation{
-				Summary:     method.Method.Caption(ctx),
-				Description: method.Method.Description(ctx),
-				OperationId: strings.Replace(path[1:], "/", "_", -1),
-				Tags:        []string{"RPC methods"},
-				RequestBody: openapi.RequestBody{
-					Description: "",
-					Required:    true,
+		operation := openapi.Operation{
+			Summary:     method.Method.Caption(ctx),
+			Description: method.Method.Description(ctx),
+			OperationId: operationId,
+			Tags:        methodTags(ctx, path, method),
+			Responses: map[string]openapi.ResponseBody{
+				"400": {
+					Description: errorsDescription,
 					Content: map[string]openapi.Content{
-						requestContentType: {
-							Schema: r.getSchema(method.Request, res.Components.Schemas),
+						"application/json": {
+							Schema: r.getErrorSchema(operationId, errors, res.Components.Schemas),
 						},
 					},
 				},
-				Responses: map[string]openapi.ResponseBody{
-					"200": {
-						Description: "### The result",
-						Content: map[string]openapi.Content{
-							"application/json": {
-								Schema: r.getSchema(method.Response, res.Components.Schemas),
-							},
+				"500": {
+					Description: "### The internal server error",
+				},
+				"503": {
+					Description: "### The service is unavailable\n" +
+						"The call should be retried after the `Retry-After` header delay.\n" +
+						"Possible codes:\n" +
+						"* **" + ErrorCodeOverloaded + "**: Too many concurrent calls\n",
+					Content: map[string]openapi.Content{
+						"application/json": {
+							Schema: r.getSchema(reflect.TypeOf(Error{}), res.Components.Schemas),
 						},
 					},
-					"400": {
-						Description: errorsDescription,
-						Content: map[string]openapi.Content{
-							"application/json": {
-								Schema: r.getSchema(reflect.TypeOf(Error{}), res.Components.Schemas),
-							},
-						},
+				},
+			},
+		}
+
+		if d, ok := method.Method.(ExternalDocsProvider); ok {
+			operation.ExternalDocs = d.ExternalDocs(ctx)
+		}
+
+		if e, ok := method.Method.(ExtensionsProvider); ok {
+			for name, value := range e.Extensions(ctx) {
+				if operation.Extensions == nil {
+					operation.Extensions = map[string]interface{}{}
+				}
+				if !strings.HasPrefix(name, "x-") {
+					name = "x-" + name
+				}
+				operation.Extensions[name] = value
+			}
+		}
+
+		if method.Request != nil {
+			requestContentType := "application/json"
+			if isMultipart(method.Request) {
+				requestContentType = "multipart/form-data"
+			}
+
+			operation.RequestBody = &openapi.RequestBody{
+				Description: "",
+				Required:    true,
+				Content: map[string]openapi.Content{
+					requestContentType: {
+						Schema: r.getSchema(method.Request, res.Components.Schemas),
+					},
+				},
+			}
+		}
+
+		switch {
+		case method.Response != nil:
+			operation.Responses["200"] = openapi.ResponseBody{
+				Description: "### The result",
+				Content: map[string]openapi.Content{
+					"application/json": {
+						Schema: r.getSchema(method.Response, res.Components.Schemas),
 					},
-					"500": {
-						Description: "### The internal server error",
+				},
+			}
+
+		case options.emptyObject:
+			operation.Responses["200"] = openapi.ResponseBody{
+				Description: "### The empty result",
+				Content: map[string]openapi.Content{
+					"application/json": {
+						Schema: openapi.Schema{Type: "object"},
 					},
 				},
+			}
+
+		default:
+			operation.Responses["204"] = openapi.ResponseBody{
+				Description: "### No content",
+			}
+		}
+
+		if h, ok := method.Method.(ResponseHeadersProvider); ok {
+			headers := map[string]openapi.Header{}
+			for name, description := range h.ResponseHeaders(ctx) {
+				headers[name] = openapi.Header{
+					Description: description,
+					Schema:      openapi.Schema{Type: "string"},
+				}
+			}
+
+			for code, response := range operation.Responses {
+				if code == "200" || code == "204" {
+					response.Headers = headers
+					operation.Responses[code] = response
+				}
+			}
+		}
+
+		res.Paths[path] = openapi.Path{
+			Post: operation,
+		}
+	}
+
+	setResponseRequired(res.Components.Schemas, requestTypes, responseTypes)
+
+	return res
+}
+
+// collectStructs adds the struct types reachable from the type to m by the schema names.
+func (r *Rpc) collectStructs(t reflect.Type, m map[string]reflect.Type) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		r.collectStructs(t.Elem(), m)
+
+	case reflect.Struct:
+		name := r.typeName(t)
+		if _, exists := m[name]; exists {
+			return
+		}
+		m[name] = t
+
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() && f.Tag.Get("json") != "-" {
+				r.collectStructs(f.Type, m)
+			}
+		}
+	}
+}
+
+// setResponseRequired sets the required fields of the response structs schemas to the fields
+// which are always present in JSON, see IsResponseOptional. The schemas of the structs
+// used in both the requests and the responses require the fields required in both.
+func setResponseRequired(schemas map[string]openapi.Schema, requestTypes, responseTypes map[string]reflect.Type) {
+	for name, t := range responseTypes {
+		schema, exists := schemas[name]
+		if !exists {
+			continue
+		}
+
+		requestRequired := map[string]bool{}
+		for _, field := range schema.Required {
+			requestRequired[field] = true
+		}
+		_, isRequest := requestTypes[name]
+
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			fieldName := f.Name
+			if jsonTag, ok := f.Tag.Lookup("json"); ok {
+				fieldName = strings.Split(jsonTag, ",")[0]
+			}
+			if fieldName == "-" || IsResponseOptional(f) {
+				continue
+			}
+
+			if !isRequest || requestRequired[fieldName] {
+				required = append(required, fieldName)
+			}
+		}
+
+		schema.Required = required
+		schemas[name] = schema
+	}
+}
+
+// getErrorSchema returns the common Error schema if there are no typed errors,
+// otherwise it returns the schemas of all the codes discriminated by the code field.
+func (r *Rpc) getErrorSchema(operationId string, errors []ErrorDesc, storage map[string]openapi.Schema) openapi.Schema {
+	typed := false
+	for _, e := range errors {
+		typed = typed || e.DataType != nil
+	}
+
+	if !typed {
+		return r.getSchema(reflect.TypeOf(Error{}), storage)
+	}
+
+	res := openapi.Schema{
+		Discriminator: &openapi.Discriminator{
+			PropertyName: "code",
+			Mapping:      map[string]string{},
+		},
+	}
+
+	for _, e := range errors {
+		code := e.Code
+		dataSchema := openapi.Schema{Type: "object"}
+		if e.DataType != nil {
+			dataSchema = r.getSchema(e.DataType, storage)
+		}
+
+		name := strings.ToLower(operationId + "_error_" + code)
+		storage[name] = openapi.Schema{
+			Type: "object",
+			Properties: map[string]openapi.Schema{
+				"code":    {Type: "string", Enum: []interface{}{code}},
+				"message": {Type: "string"},
+				"data":    dataSchema,
 			},
+			Required: []string{"code"},
 		}
+
+		ref := "#/components/schemas/" + name
+		res.OneOf = append(res.OneOf, openapi.Schema{Ref: ref})
+		res.Discriminator.Mapping[code] = ref
 	}
 
 	return res
@@ -153,6 +356,7 @@ func (r *Rpc) getSchema(t reflect.Type, storage map[string]openapi.Schema) opena
 		if _, exists := storage[name]; !exists {
 			jsonDataFields := make(map[string]openapi.Schema)
 			fileFields := make(map[string]openapi.Schema)
+			var jsonDataRequired, fileRequired []string
 
 			for i := 0; i < t.NumField(); i++ {
 				f := t.Field(i)
@@ -172,13 +376,23 @@ func (r *Rpc) getSchema(t reflect.Type, storage map[string]openapi.Schema) opena
 				if err := addFieldRestrictions(f, &fieldSchema); err != nil {
 					panic(fmt.Sprintf("Invalid validator value: %v", err))
 				}
+				if value, exists, err := GetDefault(f); err != nil {
+					panic(fmt.Sprintf("Invalid default value: %v", err))
+				} else if exists {
+					fieldSchema.Default = value
+				}
 
 				if f.Type == reflect.TypeOf((*File)(nil)).Elem() {
 					fileFields[name] = fieldSchema
+					if IsRequired(f) {
+						fileRequired = append(fileRequired, name)
+					}
 					continue
 				}
 				jsonDataFields[name] = fieldSchema
-
+				if IsRequired(f) {
+					jsonDataRequired = append(jsonDataRequired, name)
+				}
 			}
 
 			schema := openapi.Schema{
@@ -191,9 +405,15 @@ func (r *Rpc) getSchema(t reflect.Type, storage map[string]openapi.Schema) opena
 				schema.Properties["json_data"] = openapi.Schema{
 					Type:       "object",
 					Properties: jsonDataFields,
+					Required:   jsonDataRequired,
+				}
+				schema.Required = fileRequired
+				if len(jsonDataRequired) > 0 {
+					schema.Required = append(schema.Required, "json_data")
 				}
 			} else {
 				schema.Properties = jsonDataFields
+				schema.Required = jsonDataRequired
 			}
 
 			storage[name] = schema
@@ -201,7 +421,15 @@ func (r *Rpc) getSchema(t reflect.Type, storage map[string]openapi.Schema) opena
 
 		return openapi.Schema{Ref: "#/components/schemas/" + name}
 
-	case reflect.Interface, reflect.Map:
+	case reflect.Map:
+		schema := openapi.Schema{Type: "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			valuesSchema := r.getSchema(t.Elem(), storage)
+			schema.AdditionalProperties = &valuesSchema
+		}
+		return schema
+
+	case reflect.Interface:
 		if t == reflect.TypeOf((*File)(nil)).Elem() {
 			return openapi.Schema{
 				Type:   "string",
@@ -231,11 +459,58 @@ func (r *Rpc) typeName(t reflect.Type) string {
 }
 
 func addFieldRestrictions(f reflect.StructField, schema *openapi.Schema) error {
-	for _, validator := range validators[f.Type.Kind()] {
+	return addRestrictions(f, nil, schema)
+}
+
+// addRestrictions adds the validators of the field to the schema. The tags not used by the slice, array or map
+// are added to its items schema, like the validation does.
+func addRestrictions(f reflect.StructField, used map[string]bool, schema *openapi.Schema) error {
+	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	f.Type = t
+
+	elemUsed := make(map[string]bool, len(used))
+	for tag := range used {
+		elemUsed[tag] = true
+	}
+
+	for _, validator := range getValidators(t.Kind()) {
+		if used[validator.Tag()] {
+			continue
+		}
+		if _, exists := f.Tag.Lookup(validator.Tag()); exists {
+			elemUsed[validator.Tag()] = true
+		}
+
 		if err := validator.ToSwaggerSchema(f, schema); err != nil {
 			return err
 		}
 	}
 
+	elemField := f
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if schema.Items != nil {
+			elemField.Type = t.Elem()
+			items := *schema.Items
+			if err := addRestrictions(elemField, elemUsed, &items); err != nil {
+				return err
+			}
+			schema.Items = &items
+		}
+
+	case reflect.Map:
+		if schema.AdditionalProperties != nil {
+			elemField.Type = t.Elem()
+			values := *schema.AdditionalProperties
+			if err := addRestrictions(elemField, elemUsed, &values); err != nil {
+				return err
+			}
+			schema.AdditionalProperties = &values
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,472 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// typeValidator is the tree of the validators of the type built on the registration.
+// The field tags are applied to the value of the field kind, the tags not used by the slice, array or map
+// are applied to its items. The map keys are validated by the tags with the key prefix, e.g. keyPattern.
+type typeValidator struct {
+	funcs  []ruleFunc
+	fields *structValidator // Shared by all the fields of the same struct type
+	elem   *typeValidator   // The slice or array items, the map values
+	key    *typeValidator   // The map keys
+}
+
+type ruleFunc struct {
+	rule     string
+	validate ValidateFunc
+}
+
+type structValidator struct {
+	fields []fieldValidator
+	custom bool // The struct implements ContextValidator or FieldsValidator
+}
+
+// ContextValidator is implemented by the request struct or the nested structs for the cross-field rules.
+// It's called after the fields tags validation. The returned FieldError or FieldErrors are reported
+// for the fields, *Error is returned as is, the rest errors are reported for the struct itself.
+type ContextValidator interface {
+	Validate(ctx context.Context) error
+}
+
+// FieldsValidator is the ContextValidator alternative returning the errors of the fields.
+type FieldsValidator interface {
+	Validate() []FieldError
+}
+
+// FieldError is the validation error of the field. The Path is the JSON pointer relative to the validated struct
+// without the leading slash, e.g. "end_date" or "items/0/name". The empty Path means the struct itself.
+// The Rule is "validate" if empty.
+type FieldError struct {
+	Path    string
+	Rule    string
+	Message string
+	Params  map[string]interface{}
+}
+
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+
+	return e.Path + ": " + e.Message
+}
+
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// ValidationError is the entry of the VALIDATION_ERROR data. The Path is the JavaScript-like path,
+// e.g. "items[3].f1", the JsonPointer is the same path as RFC 6901 JSON pointer, e.g. "/items/3/f1".
+// The Rule is the validator tag, e.g. "minimum", and the Params are used in the message.
+type ValidationError struct {
+	Path        string                 `json:"path"`
+	JsonPointer string                 `json:"json_pointer"`
+	Rule        string                 `json:"rule"`
+	Message     string                 `json:"message"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+}
+
+// RuleError is the ValidateFunc error with the message parameters, e.g. {"value": 1, "minimum": 10}.
+type RuleError struct {
+	Message string
+	Params  map[string]interface{}
+}
+
+func (e *RuleError) Error() string {
+	return e.Message
+}
+
+// valuePath is the path of the validated value in both the JavaScript-like and JSON pointer forms.
+type valuePath struct {
+	path    string
+	pointer string
+}
+
+func (p valuePath) field(name string) valuePath {
+	path := name
+	if p.path != "" {
+		path = p.path + "." + name
+	}
+
+	return valuePath{path: path, pointer: p.pointer + "/" + escapePointer(name)}
+}
+
+func (p valuePath) index(key string) valuePath {
+	return valuePath{path: p.path + "[" + key + "]", pointer: p.pointer + "/" + escapePointer(key)}
+}
+
+// relative returns the path of the JSON pointer without the leading slash relative to the path.
+// The numeric tokens are the array indexes.
+func (p valuePath) relative(pointer string) valuePath {
+	if pointer == "" {
+		return p
+	}
+
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if _, err := strconv.Atoi(token); err == nil {
+			p = p.index(token)
+		} else {
+			p = p.field(token)
+		}
+	}
+
+	return p
+}
+
+func (p valuePath) error(rule string, err error) ValidationError {
+	res := ValidationError{
+		Path:        p.path,
+		JsonPointer: p.pointer,
+		Rule:        rule,
+		Message:     err.Error(),
+	}
+
+	var ruleErr *RuleError
+	if errors.As(err, &ruleErr) {
+		res.Params = ruleErr.Params
+	}
+
+	return res
+}
+
+// typeError returns the VALIDATION_ERROR of the JSON value of the wrong type.
+// The dotted Field of the error has the json names and the array indexes.
+func typeError(err *json.UnmarshalTypeError) *Error {
+	path := valuePath{}
+	if err.Field != "" {
+		for _, token := range strings.Split(err.Field, ".") {
+			if _, convErr := strconv.Atoi(token); convErr == nil {
+				path = path.index(token)
+			} else {
+				path = path.field(token)
+			}
+		}
+	}
+
+	return newValidationError([]ValidationError{path.error("type", ruleError("type", map[string]interface{}{
+		"value": err.Value,
+		"type":  jsonTypeName(err.Type),
+	}))})
+}
+
+// jsonTypeName returns the JSON schema type of the Go type.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// newValidationError returns VALIDATION_ERROR with the errors as the data.
+func newValidationError(errs []ValidationError) *Error {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Message
+		if e.Path != "" {
+			msgs[i] = e.Path + ": " + e.Message
+		}
+	}
+
+	return &Error{Code: ErrorCodeValidation, Message: strings.Join(msgs, "; "), Data: errs}
+}
+
+var (
+	contextValidatorType = reflect.TypeOf((*ContextValidator)(nil)).Elem()
+	fieldsValidatorType  = reflect.TypeOf((*FieldsValidator)(nil)).Elem()
+)
+
+type fieldValidator struct {
+	index     int
+	name      string // The json name, empty for the embedded structs
+	validator *typeValidator
+}
+
+// newTypeValidator returns the validator of the f.Type values, nil if there is nothing to validate.
+// The used tags are already applied to the outer value. The curPath with the Go names is used in the problems.
+func newTypeValidator(f reflect.StructField, used map[string]bool, structs map[reflect.Type]*structValidator, curPath string) (*typeValidator, []error) {
+	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	f.Type = t
+
+	res := &typeValidator{}
+	var problems []error
+
+	var usedHere []string
+	for _, v := range getValidators(t.Kind()) {
+		if used[v.Tag()] {
+			continue
+		}
+
+		vFunc, err := v.GetValidateFunc(f)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: invalid validator: %w", curPath, err))
+			continue
+		}
+
+		if vFunc != nil {
+			res.funcs = append(res.funcs, ruleFunc{rule: v.Tag(), validate: vFunc})
+			usedHere = append(usedHere, v.Tag())
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		elemUsed := make(map[string]bool, len(used)+len(usedHere))
+		for tag := range used {
+			elemUsed[tag] = true
+		}
+		for _, tag := range usedHere {
+			elemUsed[tag] = true
+		}
+
+		elemField := f
+		elemField.Type = t.Elem()
+		var elemProblems []error
+		res.elem, elemProblems = newTypeValidator(elemField, elemUsed, structs, curPath+"[]")
+		problems = append(problems, elemProblems...)
+
+		if t.Kind() == reflect.Map {
+			var keyProblems []error
+			res.key, keyProblems = newTypeValidator(keyField(f, t.Key()), nil, structs, curPath+"[key]")
+			problems = append(problems, keyProblems...)
+		}
+
+	case reflect.Struct:
+		var structProblems []error
+		res.fields, structProblems = newStructValidator(t, structs, curPath)
+		problems = append(problems, structProblems...)
+	}
+
+	if len(res.funcs) == 0 && res.fields == nil && res.elem == nil && res.key == nil {
+		return nil, problems
+	}
+
+	return res, problems
+}
+
+// keyField returns the field with the tags for the map keys, e.g. keyPattern becomes pattern.
+func keyField(f reflect.StructField, keyType reflect.Type) reflect.StructField {
+	var tag strings.Builder
+	for _, v := range getValidators(keyType.Kind()) {
+		name := v.Tag()
+		if value, exists := f.Tag.Lookup("key" + strings.ToUpper(name[:1]) + name[1:]); exists {
+			tag.WriteString(name + ":" + strconv.Quote(value) + " ")
+		}
+	}
+
+	return reflect.StructField{
+		Name: f.Name,
+		Type: keyType,
+		Tag:  reflect.StructTag(strings.TrimSpace(tag.String())),
+	}
+}
+
+func newStructValidator(t reflect.Type, structs map[reflect.Type]*structValidator, curPath string) (*structValidator, []error) {
+	if sv, exists := structs[t]; exists { // Already built or it's the recursive type
+		return sv, nil
+	}
+
+	sv := &structValidator{
+		custom: reflect.PtrTo(t).Implements(contextValidatorType) || reflect.PtrTo(t).Implements(fieldsValidatorType),
+	}
+	structs[t] = sv
+
+	var problems []error
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, tagged := f.Name, false
+		if tag, exists := f.Tag.Lookup("json"); exists {
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name, tagged = tagName, true
+			}
+		}
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && !tagged { // The embedded struct fields are at the same level in JSON
+			name = ""
+		}
+
+		fv, fieldProblems := newTypeValidator(f, nil, structs, curPath+"/"+f.Name)
+		problems = append(problems, fieldProblems...)
+		if fv != nil {
+			sv.fields = append(sv.fields, fieldValidator{index: i, name: name, validator: fv})
+		}
+	}
+
+	if len(sv.fields) == 0 && !sv.custom {
+		structs[t] = nil
+		return nil, problems
+	}
+
+	return sv, problems
+}
+
+// validate adds the validation errors of the value to errs. It returns the error only if the validation
+// cannot be continued, e.g. the business error returned by ContextValidator.
+func (tv *typeValidator) validate(ctx context.Context, v reflect.Value, path valuePath, errs *[]ValidationError) error {
+	if tv == nil {
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	for _, f := range tv.funcs {
+		if err := f.validate(v.Interface()); err != nil {
+			*errs = append(*errs, path.error(f.rule, err))
+		}
+	}
+
+	if tv.fields != nil {
+		for _, f := range tv.fields.fields {
+			fieldPath := path
+			if f.name != "" {
+				fieldPath = path.field(f.name)
+			}
+
+			if err := f.validator.validate(ctx, v.Field(f.index), fieldPath, errs); err != nil {
+				return err
+			}
+		}
+
+		if tv.fields.custom {
+			if err := validateStruct(ctx, v, path, errs); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if tv.elem == nil {
+			break
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := tv.elem.validate(ctx, v.Index(i), path.index(strconv.Itoa(i)), errs); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if tv.elem == nil && tv.key == nil {
+			break
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+
+		for _, key := range keys {
+			keyPath := path.index(fmt.Sprint(key.Interface()))
+			if err := tv.key.validate(ctx, key, keyPath, errs); err != nil {
+				return err
+			}
+			if err := tv.elem.validate(ctx, v.MapIndex(key), keyPath, errs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateStruct calls the ContextValidator or FieldsValidator of the struct.
+func validateStruct(ctx context.Context, v reflect.Value, path valuePath, errs *[]ValidationError) error {
+	ptr := reflect.New(v.Type())
+	if v.CanAddr() {
+		ptr = v.Addr()
+	} else { // The map values
+		ptr.Elem().Set(v)
+	}
+
+	var fieldErrors FieldErrors
+	switch s := ptr.Interface().(type) {
+	case ContextValidator:
+		err := s.Validate(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var (
+			rpcErr *Error
+			fe     FieldError
+		)
+		switch {
+		case errors.As(err, &rpcErr):
+			return rpcErr
+		case errors.As(err, &fieldErrors):
+		case errors.As(err, &fe):
+			fieldErrors = FieldErrors{fe}
+		default:
+			fieldErrors = FieldErrors{{Message: err.Error()}}
+		}
+
+	case FieldsValidator:
+		fieldErrors = s.Validate()
+	}
+
+	for _, fe := range fieldErrors {
+		rule := fe.Rule
+		if rule == "" {
+			rule = "validate"
+		}
+
+		*errs = append(*errs, path.relative(fe.Path).error(rule, &RuleError{Message: fe.Message, Params: fe.Params}))
+	}
+
+	return nil
+}
+
+// escapePointer escapes the JSON pointer reference token, see RFC 6901.
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
@@ -2,289 +2,345 @@ package rpc
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
 	"strconv"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/go-qbit/rpc/openapi"
 )
 
+// ValidateFunc checks the field value. The pointers are dereferenced, so the value has
+// one of the kinds the validator is registered for.
+type ValidateFunc func(v interface{}) error
+
+// SchemaFunc adds the validator restrictions to the field OpenAPI schema.
+type SchemaFunc func(schema *openapi.Schema)
+
+// ValidatorFactory parses the tag value of the field on the method registration.
+// It returns the function validating the field values and the function documenting the restrictions,
+// both are optional.
+type ValidatorFactory func(value string, f reflect.StructField) (ValidateFunc, SchemaFunc, error)
+
 type validator interface {
+	Tag() string
 	ToSwaggerSchema(f reflect.StructField, schema *openapi.Schema) error
-	GetValidateFunc(f reflect.StructField) (validateFunc, error)
+	GetValidateFunc(f reflect.StructField) (ValidateFunc, error)
 }
 
-type validateFunc func(v interface{}) error
+var (
+	validatorsMtx sync.RWMutex
+	validators    = map[reflect.Kind][]validator{}
+)
 
-type vMinimumInt struct{}
+// RegisterValidator adds the validator of the tag for the fields of the kinds, e.g. currency:"EUR,USD".
+// The validator of the same tag and kind is replaced. The validators must be registered before the methods using them.
+func RegisterValidator(tag string, kinds []reflect.Kind, factory ValidatorFactory) {
+	addValidator(tagValidator{tag: tag, factory: factory}, kinds)
+}
 
-func (v vMinimumInt) GetValue(f reflect.StructField) (interface{}, error) {
-	if t, exists := f.Tag.Lookup("minimum"); exists {
-		v, err := strconv.ParseInt(t, 10, 64)
-		if err != nil {
-			return nil, err
+func addValidator(v validator, kinds []reflect.Kind) {
+	validatorsMtx.Lock()
+	defer validatorsMtx.Unlock()
+
+	for _, kind := range kinds {
+		// The slices are never modified, so the readers can use them without the lock
+		kindValidators := make([]validator, 0, len(validators[kind])+1)
+		replaced := false
+		for _, existing := range validators[kind] {
+			if existing.Tag() == v.Tag() {
+				existing, replaced = v, true
+			}
+			kindValidators = append(kindValidators, existing)
+		}
+		if !replaced {
+			kindValidators = append(kindValidators, v)
 		}
-		return v, nil
+
+		validators[kind] = kindValidators
 	}
+}
 
-	return nil, nil
+func getValidators(kind reflect.Kind) []validator {
+	validatorsMtx.RLock()
+	defer validatorsMtx.RUnlock()
+
+	return validators[kind]
 }
 
-func (v vMinimumInt) ToSwaggerSchema(f reflect.StructField, schema *openapi.Schema) error {
-	val, err := v.GetValue(f)
-	if err != nil {
-		return err
+// tagValidator is the validator created by the factory if the field has the tag.
+type tagValidator struct {
+	tag     string
+	factory ValidatorFactory
+}
+
+func (v tagValidator) Tag() string { return v.tag }
+
+func (v tagValidator) create(f reflect.StructField) (ValidateFunc, SchemaFunc, error) {
+	value, exists := f.Tag.Lookup(v.tag)
+	if !exists {
+		return nil, nil, nil
 	}
 
-	if val != nil {
-		schema.Minimum = val
+	validate, schema, err := v.factory(value, f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", v.tag, err)
 	}
 
-	return nil
+	return validate, schema, nil
 }
 
-func (v vMinimumInt) GetValidateFunc(f reflect.StructField) (validateFunc, error) {
-	targetVal, err := v.GetValue(f)
+func (v tagValidator) ToSwaggerSchema(f reflect.StructField, schema *openapi.Schema) error {
+	_, schemaFunc, err := v.create(f)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if targetVal == nil {
-		return nil, nil
+	if schemaFunc != nil {
+		schemaFunc(schema)
 	}
 
-	return func(v interface{}) error {
-		var intVal int64
-		switch v := v.(type) {
-		case int:
-			intVal = int64(v)
-		case int8:
-			intVal = int64(v)
-		case int16:
-			intVal = int64(v)
-		case int32:
-			intVal = int64(v)
-		case int64:
-			intVal = v
-		default:
-			panic("Unknown int type")
-		}
+	return nil
+}
 
-		if intVal < targetVal.(int64) {
-			name := f.Name
-			if tag, exists := f.Tag.Lookup("json"); exists {
-				name = tag
-			}
-			return fmt.Errorf("%s=%d is less than requred minimum %d", name, intVal, targetVal.(int64))
-		}
+func (v tagValidator) GetValidateFunc(f reflect.StructField) (ValidateFunc, error) {
+	validate, _, err := v.create(f)
 
-		return nil
-	}, nil
+	return validate, err
 }
 
-type vMinimumUint struct{}
+var (
+	intKinds    = []reflect.Kind{reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64}
+	uintKinds   = []reflect.Kind{reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64}
+	floatKinds  = []reflect.Kind{reflect.Float32, reflect.Float64}
+	stringKinds = []reflect.Kind{reflect.String}
+)
 
-func (v vMinimumUint) GetValue(f reflect.StructField) (interface{}, error) {
-	if t, exists := f.Tag.Lookup("minimum"); exists {
-		v, err := strconv.ParseUint(t, 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		return v, nil
-	}
+func init() {
+	registerNumberValidators(intKinds, func(val, bound int64) bool { return val%bound != 0 })
+	registerNumberValidators(uintKinds, func(val, bound uint64) bool { return val%bound != 0 })
+	registerNumberValidators(floatKinds, func(val, bound float64) bool {
+		q := val / bound
+		return math.Abs(q-math.Round(q)) > 1e-9
+	})
+
+	RegisterValidator("pattern", stringKinds, patternValidator)
+	addValidator(vEnum{}, append(append(append(append([]reflect.Kind{}, intKinds...), uintKinds...), floatKinds...), stringKinds...))
+	RegisterValidator("format", stringKinds, formatValidator)
+	registerSizeValidators("minLength", "maxLength", stringKinds, runesCount,
+		func(schema *openapi.Schema, bound int64) { schema.MinLength = &bound },
+		func(schema *openapi.Schema, bound int64) { schema.MaxLength = &bound },
+	)
+
+	RegisterValidator("uniqueItems", []reflect.Kind{reflect.Slice, reflect.Array}, uniqueItemsValidator)
+	registerSizeValidators("minItems", "maxItems", []reflect.Kind{reflect.Slice}, itemsCount,
+		func(schema *openapi.Schema, bound int64) { schema.MinItems = &bound },
+		func(schema *openapi.Schema, bound int64) { schema.MaxItems = &bound },
+	)
+}
 
-	return nil, nil
+type number interface {
+	int64 | uint64 | float64
 }
 
-func (v vMinimumUint) ToSwaggerSchema(f reflect.StructField, schema *openapi.Schema) error {
-	val, err := v.GetValue(f)
-	if err != nil {
-		return err
+func parseNumber[T number](s string) (T, error) {
+	var (
+		res T
+		err error
+	)
+
+	switch p := any(&res).(type) {
+	case *int64:
+		*p, err = strconv.ParseInt(s, 10, 64)
+	case *uint64:
+		*p, err = strconv.ParseUint(s, 10, 64)
+	case *float64:
+		*p, err = strconv.ParseFloat(s, 64)
 	}
 
-	if val != nil {
-		schema.Minimum = val
+	return res, err
+}
+
+func numberValue[T number](v interface{}) T {
+	var res T
+
+	rv := reflect.ValueOf(v)
+	switch p := any(&res).(type) {
+	case *int64:
+		*p = rv.Int()
+	case *uint64:
+		*p = rv.Uint()
+	case *float64:
+		*p = rv.Float()
 	}
 
-	return nil
+	return res
 }
 
-func (v vMinimumUint) GetValidateFunc(f reflect.StructField) (validateFunc, error) {
-	targetVal, err := v.GetValue(f)
-	if err != nil {
-		return nil, err
-	}
+func runesCount(v interface{}) int64 {
+	return int64(utf8.RuneCountInString(reflect.ValueOf(v).String()))
+}
 
-	if targetVal == nil {
-		return nil, nil
-	}
+func itemsCount(v interface{}) int64 {
+	return int64(reflect.ValueOf(v).Len())
+}
 
-	return func(v interface{}) error {
-		var uintVal uint64
-		switch v := v.(type) {
-		case uint:
-			uintVal = uint64(v)
-		case uint8:
-			uintVal = uint64(v)
-		case uint16:
-			uintVal = uint64(v)
-		case uint32:
-			uintVal = uint64(v)
-		case uint64:
-			uintVal = v
-		default:
-			panic("Unknown int type")
+// boundValidator checks the value got from the field (the number itself, the string length or the items count)
+// against the bound set by the tag. The error params are the value and the bound keyed by the rule.
+func boundValidator[T number](rule string, value func(v interface{}) T, fails func(val, bound T) bool, toSchema func(schema *openapi.Schema, bound T)) ValidatorFactory {
+	return func(tag string, f reflect.StructField) (ValidateFunc, SchemaFunc, error) {
+		bound, err := parseNumber[T](tag)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		if uintVal < targetVal.(uint64) {
-			name := f.Name
-			if tag, exists := f.Tag.Lookup("json"); exists {
-				name = tag
+		validate := func(v interface{}) error {
+			if x := value(v); fails(x, bound) {
+				return ruleError(rule, map[string]interface{}{"value": x, rule: bound})
 			}
-			return fmt.Errorf("%s=%d is less than requred minimum %d", name, uintVal, targetVal.(uint64))
+
+			return nil
 		}
 
-		return nil
-	}, nil
+		return validate, func(schema *openapi.Schema) { toSchema(schema, bound) }, nil
+	}
 }
 
-type vMaximumInt struct{}
-
-func (v vMaximumInt) GetValue(f reflect.StructField) (interface{}, error) {
-	if t, exists := f.Tag.Lookup("maximum"); exists {
-		v, err := strconv.ParseInt(t, 10, 64)
-		if err != nil {
-			return nil, err
+// positive makes the factory reject the non-positive bounds.
+func positive[T number](factory ValidatorFactory) ValidatorFactory {
+	return func(tag string, f reflect.StructField) (ValidateFunc, SchemaFunc, error) {
+		if bound, err := parseNumber[T](tag); err == nil && bound <= 0 {
+			return nil, nil, fmt.Errorf("must be positive")
 		}
-		return v, nil
-	}
 
-	return nil, nil
+		return factory(tag, f)
+	}
 }
 
-func (v vMaximumInt) ToSwaggerSchema(f reflect.StructField, schema *openapi.Schema) error {
-	val, err := v.GetValue(f)
-	if err != nil {
-		return err
-	}
+func registerNumberValidators[T number](kinds []reflect.Kind, notMultiple func(val, bound T) bool) {
+	RegisterValidator("minimum", kinds, boundValidator("minimum", numberValue[T],
+		func(val, bound T) bool { return val < bound },
+		func(schema *openapi.Schema, bound T) { schema.Minimum = bound },
+	))
+
+	RegisterValidator("maximum", kinds, boundValidator("maximum", numberValue[T],
+		func(val, bound T) bool { return val > bound },
+		func(schema *openapi.Schema, bound T) { schema.Maximum = bound },
+	))
+
+	RegisterValidator("exclusiveMinimum", kinds, boundValidator("exclusiveMinimum", numberValue[T],
+		func(val, bound T) bool { return val <= bound },
+		func(schema *openapi.Schema, bound T) {
+			schema.Minimum = bound
+			schema.ExclusiveMinimum = true
+		},
+	))
+
+	RegisterValidator("exclusiveMaximum", kinds, boundValidator("exclusiveMaximum", numberValue[T],
+		func(val, bound T) bool { return val >= bound },
+		func(schema *openapi.Schema, bound T) {
+			schema.Maximum = bound
+			schema.ExclusiveMaximum = true
+		},
+	))
+
+	RegisterValidator("multipleOf", kinds, positive[T](boundValidator("multipleOf", numberValue[T],
+		notMultiple,
+		func(schema *openapi.Schema, bound T) { schema.MultipleOf = bound },
+	)))
+}
 
-	if val != nil {
-		schema.Minimum = val
-	}
+func registerSizeValidators(minTag, maxTag string, kinds []reflect.Kind, value func(v interface{}) int64, setMin, setMax func(schema *openapi.Schema, bound int64)) {
+	RegisterValidator(minTag, kinds, boundValidator(minTag, value,
+		func(val, bound int64) bool { return val < bound },
+		setMin,
+	))
 
-	return nil
+	RegisterValidator(maxTag, kinds, boundValidator(maxTag, value,
+		func(val, bound int64) bool { return val > bound },
+		setMax,
+	))
 }
 
-func (v vMaximumInt) GetValidateFunc(f reflect.StructField) (validateFunc, error) {
-	targetVal, err := v.GetValue(f)
+func uniqueItemsValidator(tag string, f reflect.StructField) (ValidateFunc, SchemaFunc, error) {
+	unique, err := strconv.ParseBool(tag)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if targetVal == nil {
-		return nil, nil
+	if !unique {
+		return nil, nil, nil
 	}
 
-	return func(v interface{}) error {
-		var intVal int64
-		switch v := v.(type) {
-		case int:
-			intVal = int64(v)
-		case int8:
-			intVal = int64(v)
-		case int16:
-			intVal = int64(v)
-		case int32:
-			intVal = int64(v)
-		case int64:
-			intVal = v
-		default:
-			panic("Unknown int type")
-		}
+	hashable := isHashable(f.Type.Elem())
 
-		if intVal > targetVal.(int64) {
-			name := f.Name
-			if tag, exists := f.Tag.Lookup("json"); exists {
-				name = tag
-			}
-			return fmt.Errorf("%s=%d is greater than requred maximum %d", name, intVal, targetVal.(int64))
-		}
+	validate := func(v interface{}) error {
+		rv := reflect.ValueOf(v)
 
-		return nil
-	}, nil
-}
+		seen := map[interface{}]struct{}{}
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i)
 
-type vPattern struct{}
+			duplicate := false
+			if hashable {
+				_, duplicate = seen[item.Interface()]
+				seen[item.Interface()] = struct{}{}
+			} else {
+				for j := 0; j < i && !duplicate; j++ {
+					duplicate = reflect.DeepEqual(rv.Index(j).Interface(), item.Interface())
+				}
+			}
 
-func (v vPattern) GetValue(f reflect.StructField) (string, error) {
-	if t, exists := f.Tag.Lookup("pattern"); exists {
-		if _, err := regexp.Compile(t); err != nil {
-			return "", err
+			if duplicate {
+				return ruleError("uniqueItems", map[string]interface{}{"index": i})
+			}
 		}
-		return t, nil
+
+		return nil
 	}
 
-	return "", nil
+	return validate, func(schema *openapi.Schema) { schema.UniqueItems = true }, nil
 }
 
-func (v vPattern) ToSwaggerSchema(f reflect.StructField, schema *openapi.Schema) error {
-	val, err := v.GetValue(f)
-	if err != nil {
-		return err
-	}
-
-	if val != "" {
-		schema.Pattern = val
+// isHashable reports whether the values of the type can be the map keys and are equal as JSON values.
+// The interfaces may hold the unhashable values, e.g. []interface{}, and the pointers are compared by the address,
+// so both are compared by reflect.DeepEqual.
+func isHashable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return false
+
+	case reflect.Array:
+		return isHashable(t.Elem())
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isHashable(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
 	}
 
-	return nil
+	return t.Comparable()
 }
 
-func (v vPattern) GetValidateFunc(f reflect.StructField) (validateFunc, error) {
-	pattern, err := v.GetValue(f)
+func patternValidator(pattern string, f reflect.StructField) (ValidateFunc, SchemaFunc, error) {
+	re, err := regexp.Compile(pattern)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if pattern == "" {
-		return nil, nil
-	}
-
-	re := regexp.MustCompile(pattern)
-	return func(v interface{}) error {
-		val := v.(string)
+	validate := func(v interface{}) error {
+		val := reflect.ValueOf(v).String()
 		if !re.MatchString(val) {
-			name := f.Name
-			if tag, exists := f.Tag.Lookup("json"); exists {
-				name = tag
-			}
-			if len(val) > 25 {
-				val = val[:22] + "..."
-			}
-			return fmt.Errorf("%s=%s does not match the pattern %s", name, val, pattern)
+			return ruleError("pattern", map[string]interface{}{"value": val, "pattern": pattern})
 		}
 
 		return nil
-	}, nil
-}
-
-var (
-	intValidators  = []validator{vMinimumInt{}, vMaximumInt{}}
-	uintValidators = []validator{vMinimumUint{}, vMinimumUint{}}
-
-	validators = map[reflect.Kind][]validator{
-		reflect.Int:   intValidators,
-		reflect.Int8:  intValidators,
-		reflect.Int16: intValidators,
-		reflect.Int32: intValidators,
-		reflect.Int64: intValidators,
-
-		reflect.Uint:   uintValidators,
-		reflect.Uint8:  uintValidators,
-		reflect.Uint16: uintValidators,
-		reflect.Uint32: uintValidators,
-		reflect.Uint64: uintValidators,
-
-		reflect.String: []validator{vPattern{}},
 	}
-)
+
+	return validate, func(schema *openapi.Schema) { schema.Pattern = pattern }, nil
+}
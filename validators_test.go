@@ -0,0 +1,553 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-qbit/rpc"
+	mHello "github.com/go-qbit/rpc/internal/test/method/hello"
+	mValidate "github.com/go-qbit/rpc/internal/test/method/validate"
+	"github.com/go-qbit/rpc/openapi"
+	"github.com/go-qbit/rpc/rpctest"
+)
+
+func TestRpc_ServeHTTP_Validators(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name  string
+		field string
+		value interface{}
+		valid bool
+	}{
+		{"valid", "int", 2, true},
+		{"minimum", "int", -2, false},
+		{"exclusiveMaximum", "int", 10, false},
+		{"multipleOf int", "int", 3, false},
+		{"uint minimum", "uint", 0, false},
+		{"uint maximum", "uint", 11, false},
+		{"uint maximum valid", "uint", 10, true},
+		{"exclusiveMinimum", "float", 0, false},
+		{"float maximum", "float", 3, false},
+		{"float maximum valid", "float", 2.5, true},
+		{"multipleOf float", "float", 0.7, false},
+		{"minLength", "str", "a", false},
+		{"maxLength", "str", "abcd", false},
+		{"maxLength runes", "str", "абв", true},
+		{"minItems", "tags", []string{}, false},
+		{"maxItems", "tags", []string{"a", "b", "c", "d"}, false},
+		{"uniqueItems", "tags", []string{"a", "b", "a"}, false},
+		{"enum string", "color", "blue", false},
+		{"enum string valid", "color", "green", true},
+		{"enum int", "level", 4, false},
+		{"enum int valid", "level", 3, true},
+		{"enum type", "state", "deleted", false},
+		{"enum type valid", "state", "blocked", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := map[string]interface{}{
+				"int": 2, "uint": 1, "float": 0.5, "str": "ab", "tags": []string{"a"},
+				"color": "red", "level": 1, "state": "active",
+			}
+			req[tc.field] = tc.value
+
+			resp, err := srv.PostJSON("/validate/v1", req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.valid {
+				if resp.Status != http.StatusNoContent {
+					t.Fatalf("Invalid status code = %d, expected 204. Data: '%s'", resp.Status, resp.Body)
+				}
+				return
+			}
+
+			rpctest.AssertError(t, resp.Err(), "VALIDATION_ERROR")
+		})
+	}
+}
+
+func TestRpc_GetSwagger_Validators(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(r.GetSwagger(context.Background()).Components.Schemas["validate_reqv1"].Properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		`"int":{"type":"integer","format":"int64","minimum":0,"maximum":10,"exclusiveMaximum":true,"multipleOf":2}`,
+		`"uint":{"type":"integer","format":"int64","minimum":1,"maximum":10}`,
+		`"float":{"type":"number","format":"double","minimum":0,"maximum":2.5,"exclusiveMinimum":true,"multipleOf":0.5}`,
+		`"str":{"type":"string","minLength":2,"maxLength":3}`,
+		`"tags":{"type":"array","items":{"type":"string"},"minItems":1,"maxItems":3,"uniqueItems":true}`,
+		`"color":{"type":"string","enum":["red","green"]}`,
+		`"level":{"type":"integer","format":"int64","enum":[1,2,3]}`,
+		`"state":{"type":"string","enum":["active","blocked"]}`,
+	} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("The schema %s does not contain %s", data, s)
+		}
+	}
+}
+
+func TestRpc_RegisterMethod_InvalidEnum(t *testing.T) {
+	r := rpc.New("")
+
+	err := rpc.Handle(r, "/enum/v1", func(ctx context.Context, req *struct {
+		Int int `json:"int" enum:"1,a"`
+	}) (*struct{}, error) {
+		return nil, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid validator") {
+		t.Fatalf("Invalid error %v", err)
+	}
+}
+
+func TestRpc_RegisterMethod_InvalidMultipleOf(t *testing.T) {
+	r := rpc.New("")
+
+	err := rpc.Handle(r, "/multiple/v1", func(ctx context.Context, req *struct {
+		Int int `json:"int" multipleOf:"0"`
+	}) (*struct{}, error) {
+		return nil, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "multipleOf: must be positive") {
+		t.Fatalf("Invalid error %v", err)
+	}
+}
+
+func TestRpc_ServeHTTP_Required(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		body    string
+		message string
+	}{
+		{`{"id": 0}`, ""},
+		{`{"id": 1, "items": [{"name": ""}], "owner": null}`, ""},
+		{`{}`, "id: is required"},
+		{`null`, "id: is required"},
+		{`{"id": 1, "items": [null]}`, "items[0].name: is required"},
+		{`{"id": 1, "items": [{"name": "a"}, {"comment": "b"}]}`, "items[1].name: is required"},
+		{`{"id": 1, "owner": {}}`, "owner.email: is required"},
+	} {
+		t.Run(tc.body, func(t *testing.T) {
+			resp, err := srv.Post("/validate/v2", "application/json", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.message == "" {
+				if resp.Status != http.StatusNoContent {
+					t.Fatalf("Invalid status code = %d, expected 204. Data: '%s'", resp.Status, resp.Body)
+				}
+				return
+			}
+
+			rpctest.AssertError(t, resp.Err(), "VALIDATION_ERROR")
+			if msg := resp.Err().(*rpc.Error).Message; msg != tc.message {
+				t.Fatalf("Invalid message '%s', expected '%s'", msg, tc.message)
+			}
+		})
+	}
+}
+
+func TestRpc_GetSwagger_Required(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := r.GetSwagger(context.Background()).Components.Schemas
+	for name, required := range map[string][]string{
+		"validate_reqv2":  {"id"},
+		"validate_itemv2": {"name"},
+		"validate_reqv1":  nil,
+	} {
+		if !reflect.DeepEqual(schemas[name].Required, required) {
+			t.Fatalf("Invalid %s required %v, expected %v", name, schemas[name].Required, required)
+		}
+	}
+}
+
+type sharedItem struct {
+	ID   int     `json:"id" required:"true"`
+	Name string  `json:"name"`
+	Note *string `json:"note" required:"true"`
+}
+
+type sharedResp struct {
+	Item  sharedItem   `json:"item"`
+	Items []sharedItem `json:"items,omitempty"`
+	Total int          `json:"total,omitempty"`
+}
+
+func TestRpc_GetSwagger_ResponseRequired(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethods(mHello.New(), mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+	if err := rpc.Handle(r, "/shared/v1", func(ctx context.Context, req *sharedItem) (*sharedResp, error) {
+		return &sharedResp{}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := r.GetSwagger(context.Background()).Components.Schemas
+	for name, required := range map[string][]string{
+		"hello_respv1":                           {"message", "data"},
+		"hello_datav1":                           {"str"},
+		"hello_errordatav1":                      {"field", "reason"},
+		"validate_reqv2":                         {"id"},
+		"github_com_go_qbit_rpc_error":           {"code"},
+		"github_com_go_qbit_rpc_validationerror": {"path", "json_pointer", "rule", "message"},
+		"github_com_go_qbit_rpc_test_sharedresp": {"item"},
+		"github_com_go_qbit_rpc_test_shareditem": {"id"},
+		"hello_v1_error_error1":                  {"code"},
+	} {
+		if !reflect.DeepEqual(schemas[name].Required, required) {
+			t.Fatalf("Invalid %s required %v, expected %v", name, schemas[name].Required, required)
+		}
+	}
+}
+
+func TestRpc_ServeHTTP_ValidatorsNested(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		body    string
+		message string
+	}{
+		{`{"items": [{"f1": 1}], "by_name": {"a": {"f1": 2}}, "scores": {"a": 0}, "codes": ["ABC"], "matrix": [[1, 9]], "states": ["active"], "colors": ["red"]}`, ""},
+		{`{"items": [{"f1": 1}, {"f1": 1}, {"f1": 1}, {"f1": 0}]}`, "items[3].f1: 0 is less than required minimum 1"},
+		{`{"by_name": {"a/b": {"f1": 0}}}`, "by_name[a/b].f1: 0 is less than required minimum 1"},
+		{`{"scores": {"a": -1}}`, "scores[a]: -1 is less than required minimum 0"},
+		{`{"scores": {"A": 1}}`, "scores[A]: A does not match the pattern ^[a-z]+$"},
+		{`{"codes": ["ABC", "abc"]}`, "codes[1]: abc does not match the pattern ^[A-Z]{3}$"},
+		{`{"codes": ["ABC", "ABC", "ABC"]}`, "codes: has 3 items, more than required maxItems 2"},
+		{`{"matrix": [[1], [2, 10]]}`, "matrix[1][1]: 10 is greater than required maximum 9"},
+		{`{"matrix": [[1], [1, 2, 3]]}`, ""},
+		{`{"states": ["deleted"]}`, "states[0]: deleted is not one of [active blocked]"},
+		{`{"colors": ["blue"]}`, "colors[0]: blue is not one of [red green]"},
+	} {
+		t.Run(tc.body, func(t *testing.T) {
+			resp, err := srv.Post("/validate/v3", "application/json", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.message == "" {
+				if resp.Status != http.StatusNoContent {
+					t.Fatalf("Invalid status code = %d, expected 204. Data: '%s'", resp.Status, resp.Body)
+				}
+				return
+			}
+
+			rpctest.AssertError(t, resp.Err(), "VALIDATION_ERROR")
+			if msg := resp.Err().(*rpc.Error).Message; msg != tc.message {
+				t.Fatalf("Invalid message '%s', expected '%s'", msg, tc.message)
+			}
+		})
+	}
+}
+
+func TestRpc_GetSwagger_ValidatorsNested(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(r.GetSwagger(context.Background()).Components.Schemas["validate_reqv3"].Properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		`"by_name":{"type":"object","additionalProperties":{"$ref":"#/components/schemas/validate_itemv3"}}`,
+		`"scores":{"type":"object","additionalProperties":{"type":"integer","format":"int64","minimum":0}}`,
+		`"codes":{"type":"array","items":{"type":"string","pattern":"^[A-Z]{3}$"},"maxItems":2}`,
+		`"matrix":{"type":"array","items":{"type":"array","items":{"type":"integer","format":"int64","maximum":9}},"maxItems":2}`,
+		`"states":{"type":"array","items":{"type":"string","enum":["active","blocked"]}}`,
+		`"colors":{"type":"array","items":{"type":"string","enum":["red","green"]}}`,
+	} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("The schema %s does not contain %s", data, s)
+		}
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	rpc.RegisterValidator("testCurrency", []reflect.Kind{reflect.String}, func(value string, f reflect.StructField) (rpc.ValidateFunc, rpc.SchemaFunc, error) {
+		if value == "" {
+			return nil, nil, errors.New("no currencies")
+		}
+
+		currencies := strings.Split(value, ",")
+		validate := func(v interface{}) error {
+			for _, c := range currencies {
+				if c == v.(string) {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s is not a supported currency", v)
+		}
+
+		return validate, func(schema *openapi.Schema) { schema.Description += " Currencies: " + value }, nil
+	})
+
+	r := rpc.New("")
+	err := rpc.Handle(r, "/pay/v1", func(ctx context.Context, req *struct {
+		Currency  string   `json:"currency" desc:"Payment currency." testCurrency:"EUR,USD"`
+		Fallbacks []string `json:"fallbacks" testCurrency:"EUR"`
+	}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	for body, message := range map[string]string{
+		`{"currency": "EUR", "fallbacks": ["EUR"]}`: "",
+		`{"currency": "RUB"}`:                       "currency: RUB is not a supported currency",
+		`{"currency": "USD", "fallbacks": ["USD"]}`: "fallbacks[0]: USD is not a supported currency",
+	} {
+		resp, err := srv.Post("/pay/v1", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if message == "" {
+			if resp.Status != http.StatusOK {
+				t.Fatalf("Invalid status code = %d, expected 200. Data: '%s'", resp.Status, resp.Body)
+			}
+			continue
+		}
+
+		rpctest.AssertError(t, resp.Err(), "VALIDATION_ERROR")
+		if msg := resp.Err().(*rpc.Error).Message; msg != message {
+			t.Fatalf("Invalid message '%s', expected '%s'", msg, message)
+		}
+	}
+
+	data, err := json.Marshal(r.GetSwagger(context.Background()).Components.Schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"description":"Payment currency. Currencies: EUR,USD"`) {
+		t.Fatalf("No validator restrictions in the schema %s", data)
+	}
+
+	err = rpc.Handle(r, "/pay/v2", func(ctx context.Context, req *struct {
+		Currency string `json:"currency" testCurrency:""`
+	}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "testCurrency: no currencies") {
+		t.Fatalf("Invalid error %v", err)
+	}
+}
+
+func TestRpc_ServeHTTP_ValidateMethod(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		body    string
+		message string
+	}{
+		{`{"start_date": "2024-01-01T00:00:00Z", "end_date": "2024-01-02T00:00:00Z", "contacts": [{"phone": "1"}], "by_name": {"a": {"name": "a"}}}`, ""},
+		{`{"start_date": "2024-01-02T00:00:00Z", "end_date": "2024-01-01T00:00:00Z"}`, "end_date: must be after start_date"},
+		{`{"contacts": [{"phone": "1"}]}`, "start_date is required for the contacts"},
+		{`{"start_date": "2024-01-01T00:00:00Z", "end_date": "2024-01-02T00:00:00Z", "contacts": [{"phone": "1"}, {}]}`, "contacts[1]: either email or phone is required"},
+		{`{"contacts": [{"email": "a"}]}`, "contacts[0].email: a is not a valid email; start_date is required for the contacts"},
+		{`{"by_name": {"a": {}}}`, "by_name[a].name: must not be empty"},
+	} {
+		t.Run(tc.body, func(t *testing.T) {
+			resp, err := srv.Post("/validate/v5", "application/json", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.message == "" {
+				if resp.Status != http.StatusNoContent {
+					t.Fatalf("Invalid status code = %d, expected 204. Data: '%s'", resp.Status, resp.Body)
+				}
+				return
+			}
+
+			rpctest.AssertError(t, resp.Err(), "VALIDATION_ERROR")
+			if msg := resp.Err().(*rpc.Error).Message; msg != tc.message {
+				t.Fatalf("Invalid message '%s', expected '%s'", msg, tc.message)
+			}
+		})
+	}
+}
+
+func TestRpc_ServeHTTP_ValidationErrors(t *testing.T) {
+	r := rpc.New("github.com/go-qbit/rpc/internal/test/method")
+	if err := r.RegisterMethod(mValidate.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := rpctest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := srv.Post("/validate/v3", "application/json", strings.NewReader(`{"items": [{"f1": 0}], "codes": ["abc", "ABC", "ABC"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rpctest.AssertError(t, resp.Err(), "VALIDATION_ERROR")
+
+	expected := []rpc.ValidationError{
+		{Path: "items[0].f1", JsonPointer: "/items/0/f1", Rule: "minimum", Message: "0 is less than required minimum 1", Params: map[string]interface{}{"value": 0.0, "minimum": 1.0}},
+		{Path: "codes", JsonPointer: "/codes", Rule: "maxItems", Message: "has 3 items, more than required maxItems 2", Params: map[string]interface{}{"value": 3.0, "maxItems": 2.0}},
+		{Path: "codes[0]", JsonPointer: "/codes/0", Rule: "pattern", Message: "abc does not match the pattern ^[A-Z]{3}$", Params: map[string]interface{}{"value": "abc", "pattern": "^[A-Z]{3}$"}},
+	}
+	if errs := rpctest.ValidationErrors(resp.Err()); !reflect.DeepEqual(errs, expected) {
+		t.Fatalf("Invalid validation errors %+v, expected %+v", errs, expected)
+	}
+
+	// The values of the wrong types are the validation errors, the syntax errors are not
+	resp, err = srv.Post("/validate/v3", "application/json", strings.NewReader(`{"items": [{"f1": "a"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpctest.AssertError(t, resp.Err(), "VALIDATION_ERROR")
+
+	expected = []rpc.ValidationError{
+		{Path: "items[0].f1", JsonPointer: "/items/0/f1", Rule: "type", Message: "is string, expected integer", Params: map[string]interface{}{"value": "string", "type": "integer"}},
+	}
+	if errs := rpctest.ValidationErrors(resp.Err()); !reflect.DeepEqual(errs, expected) {
+		t.Fatalf("Invalid validation errors %+v, expected %+v", errs, expected)
+	}
+
+	resp, err = srv.Post("/validate/v3", "application/json", strings.NewReader(`{"items": [{"f1": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpctest.AssertError(t, resp.Err(), "INVALID_JSON")
+
+	_, err = r.Invoke(context.Background(), "/validate/v1", []byte(`{"int": "abc"}`))
+	rpctest.AssertError(t, err, "VALIDATION_ERROR")
+	if msg := err.(*rpc.Error).Message; msg != "int: is string, expected integer" {
+		t.Fatalf("Invalid message '%s'", msg)
+	}
+
+	schema, exists := r.GetSwagger(context.Background()).Components.Schemas["validate_v3_error_validation_error"]
+	if !exists || schema.Properties["data"].Type != "array" {
+		t.Fatalf("Invalid VALIDATION_ERROR schema %+v", schema)
+	}
+}
+
+func TestRpc_Invoke_UniqueItemsUnhashable(t *testing.T) {
+	r := rpc.New("")
+	err := rpc.Handle(r, "/unique/v1", func(ctx context.Context, req *struct {
+		Items []interface{} `json:"items" uniqueItems:"true"`
+		Ptrs  []*int        `json:"ptrs" uniqueItems:"true"`
+	}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for body, pointer := range map[string]string{
+		`{"items": [[1], [2], {"a": 1}, 1], "ptrs": [1, 2]}`: "",
+		`{"items": [[1], [1]]}`:                              "/items",
+		`{"items": [{"a": [1]}, {"a": [1]}]}`:                "/items",
+		`{"ptrs": [1, 1]}`:                                   "/ptrs",
+	} {
+		_, err := r.Invoke(context.Background(), "/unique/v1", []byte(body))
+		if pointer == "" {
+			if err != nil {
+				t.Fatalf("Invalid error %v for %s", err, body)
+			}
+			continue
+		}
+
+		rpctest.AssertError(t, err, "VALIDATION_ERROR")
+		if errs := rpctest.ValidationErrors(err); len(errs) != 1 || errs[0].JsonPointer != pointer || errs[0].Rule != "uniqueItems" {
+			t.Fatalf("Invalid validation errors %+v for %s", errs, body)
+		}
+	}
+}
+
+type ratio32 float32
+
+func (r ratio32) Enum() []any {
+	return []any{0.25, 0.1}
+}
+
+func TestRpc_Invoke_EnumFloat32(t *testing.T) {
+	r := rpc.New("")
+	err := rpc.Handle(r, "/ratio/v1", func(ctx context.Context, req *struct {
+		F     float32 `json:"f" enum:"0.1,0.5"`
+		Ratio ratio32 `json:"ratio"`
+	}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for body, valid := range map[string]bool{
+		`{"f": 0.1, "ratio": 0.1}`:  true,
+		`{"f": 0.5, "ratio": 0.25}`: true,
+		`{"f": 0.2, "ratio": 0.1}`:  false,
+		`{"f": 0.1, "ratio": 0.2}`:  false,
+	} {
+		_, err := r.Invoke(context.Background(), "/ratio/v1", []byte(body))
+		if valid && err != nil {
+			t.Fatalf("Invalid error %v for %s", err, body)
+		}
+		if !valid {
+			rpctest.AssertError(t, err, "VALIDATION_ERROR")
+		}
+	}
+
+	data, err := json.Marshal(r.GetSwagger(context.Background()).Components.Schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"enum":[0.1,0.5]`) || !strings.Contains(string(data), `"enum":[0.25,0.1]`) {
+		t.Fatalf("Invalid enums in the schema %s", data)
+	}
+}